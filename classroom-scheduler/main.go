@@ -4,12 +4,18 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"scheduler/web"
 )
 
 const httpPort = 8080
 
 func main() {
+	// `scheduler migrate status` checks a database without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(web.MigrateCommand(os.Args[2:]))
+	}
+
 	// 1. Database + cache
 	web.OpenDBAndLoadCaches()
 
@@ -20,5 +26,5 @@ func main() {
 	web.SetupRoutes()
 
 	log.Printf("    http://localhost:%d", httpPort)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", httpPort), web.LoggingMiddleware(http.DefaultServeMux)))
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", httpPort), web.LoggingMiddleware(web.AuditMiddleware(web.EventMiddleware(http.DefaultServeMux)))))
 }
\ No newline at end of file
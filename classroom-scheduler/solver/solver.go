@@ -0,0 +1,401 @@
+// Package solver proposes where a pool of sessions should go: which room
+// and which block. It knows nothing about the database or the web pages –
+// the caller describes rooms, blocks and what is already booked, and gets
+// back a proposal to review.
+package solver
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Room is a classroom sessions can be placed in.
+type Room struct {
+	ID       int
+	Capacity int // seats, 0 = unknown (fits anyone)
+}
+
+// Slot is a session block.
+type Slot struct {
+	ID    int
+	Day   string // YYYY-MM-DD, "" for single-day events
+	Start string // HH:MM
+	End   string // HH:MM
+}
+
+// Item is one session from the pool.
+type Item struct {
+	ID       int
+	People   []string // presenters and team names
+	Audience int      // expected attendees, 0 = unknown
+	Track    string   // preferred track, "" = none
+	Minutes  int      // length needed, 0 = any block
+}
+
+// Booking is a session already on the schedule. It holds its cell and keeps
+// its presenters busy from Start to End.
+type Booking struct {
+	RoomID int
+	SlotID int
+	Day    string
+	Start  string
+	End    string
+	People []string
+	Track  string
+}
+
+// Problem is everything Solve needs.
+type Problem struct {
+	Rooms  []Room
+	Slots  []Slot
+	Items  []Item
+	Booked []Booking
+}
+
+// Assignment places one item in a room and slot.
+type Assignment struct {
+	ItemID int
+	RoomID int
+	SlotID int
+}
+
+// Unplaced is an item the solver could not fit, and why.
+type Unplaced struct {
+	ItemID int
+	Reason string
+}
+
+// Result is a proposal – nothing is applied until the caller does so.
+type Result struct {
+	Assignments  []Assignment
+	Unplaced     []Unplaced
+	TrackClashes int // same-track sessions running at the same time
+}
+
+// searchBudget caps how many partial schedules Solve looks at before it
+// settles for the best one found so far.
+const searchBudget = 50000
+
+// maxBranches is how many of an item's best cells are tried per step.
+const maxBranches = 6
+
+// Solve assigns as many items as it can, then keeps same-track sessions
+// apart and puts each session in the smallest room that holds its audience.
+//
+// Hard rules: one session per room and slot, the room holds the expected
+// audience, the slot is long enough, and nobody presents twice at once.
+func Solve(p Problem) Result {
+	s := newSearch(p)
+
+	// Most constrained first: fewest possible cells, then biggest audience
+	order := make([]int, len(p.Items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ia, ib := p.Items[order[a]], p.Items[order[b]]
+		na, nb := len(s.candidates[order[a]]), len(s.candidates[order[b]])
+		if na != nb {
+			return na < nb
+		}
+		if ia.Audience != ib.Audience {
+			return ia.Audience > ib.Audience
+		}
+		return ia.ID < ib.ID
+	})
+	s.order = order
+	s.run(0, 0, 0)
+
+	var res Result
+	res.TrackClashes = s.bestClashes
+	for i, item := range p.Items {
+		if c, ok := s.best[i]; ok {
+			res.Assignments = append(res.Assignments, Assignment{item.ID, p.Rooms[c.room].ID, p.Slots[c.slot].ID})
+		} else {
+			res.Unplaced = append(res.Unplaced, Unplaced{item.ID, s.reason(i)})
+		}
+	}
+	return res
+}
+
+type cell struct{ room, slot int }
+
+type search struct {
+	p          Problem
+	candidates [][]cell // cells each item could ever use, ignoring other items
+	order      []int
+
+	taken  map[cell]bool
+	busy   map[string][]int // person → slots they present in (placed items only)
+	tracks map[int][]string // slot → tracks placed in it
+	chosen map[int]cell     // item → cell
+
+	nodes       int
+	best        map[int]cell
+	bestPlaced  int
+	bestCost    int
+	bestClashes int
+}
+
+func newSearch(p Problem) *search {
+	s := &search{
+		p:        p,
+		taken:    make(map[cell]bool),
+		busy:     make(map[string][]int),
+		tracks:   make(map[int][]string),
+		chosen:   make(map[int]cell),
+		best:     make(map[int]cell),
+		bestCost: -1,
+	}
+	roomIdx := make(map[int]int)
+	for i, r := range p.Rooms {
+		roomIdx[r.ID] = i
+	}
+	slotIdx := make(map[int]int)
+	for i, sl := range p.Slots {
+		slotIdx[sl.ID] = i
+	}
+	for _, b := range p.Booked {
+		r, okR := roomIdx[b.RoomID]
+		sl, okS := slotIdx[b.SlotID]
+		if okR && okS {
+			s.taken[cell{r, sl}] = true
+		}
+	}
+
+	s.candidates = make([][]cell, len(p.Items))
+	for i, item := range p.Items {
+		for sl := range p.Slots {
+			if !s.slotFits(item, sl) || s.bookedClash(item, sl) {
+				continue
+			}
+			for r := range p.Rooms {
+				if roomFits(p.Rooms[r], item) && !s.taken[cell{r, sl}] {
+					s.candidates[i] = append(s.candidates[i], cell{r, sl})
+				}
+			}
+		}
+	}
+	return s
+}
+
+// run places order[k:] – placed and cost describe order[:k].
+func (s *search) run(k, placed, cost int) {
+	s.nodes++
+	if placed > s.bestPlaced || (placed == s.bestPlaced && (s.bestCost < 0 || cost < s.bestCost)) {
+		s.bestPlaced, s.bestCost = placed, cost
+		s.bestClashes = s.clashes()
+		s.best = make(map[int]cell, len(s.chosen))
+		for i, c := range s.chosen {
+			s.best[i] = c
+		}
+	}
+	if k == len(s.order) || s.nodes >= searchBudget {
+		return
+	}
+	// Can't beat the best even if everything left fits
+	remaining := len(s.order) - k
+	if placed+remaining < s.bestPlaced || (placed+remaining == s.bestPlaced && cost >= s.bestCost) {
+		return
+	}
+
+	i := s.order[k]
+	type option struct {
+		c    cell
+		cost int
+	}
+	var opts []option
+	for _, c := range s.candidates[i] {
+		if s.taken[c] || s.personClash(s.p.Items[i], c.slot) {
+			continue
+		}
+		opts = append(opts, option{c, s.cellCost(s.p.Items[i], c)})
+	}
+	sort.SliceStable(opts, func(a, b int) bool { return opts[a].cost < opts[b].cost })
+	if len(opts) > maxBranches {
+		opts = opts[:maxBranches]
+	}
+
+	for _, o := range opts {
+		s.place(i, o.c)
+		s.run(k+1, placed+1, cost+o.cost)
+		s.unplace(i, o.c)
+		if s.nodes >= searchBudget {
+			return
+		}
+	}
+	// Leaving this one out may let the rest fit
+	s.run(k+1, placed, cost)
+}
+
+func (s *search) place(i int, c cell) {
+	item := s.p.Items[i]
+	s.taken[c] = true
+	s.chosen[i] = c
+	for _, name := range item.People {
+		key := strings.ToLower(name)
+		s.busy[key] = append(s.busy[key], c.slot)
+	}
+	s.tracks[c.slot] = append(s.tracks[c.slot], item.Track)
+}
+
+func (s *search) unplace(i int, c cell) {
+	item := s.p.Items[i]
+	delete(s.taken, c)
+	delete(s.chosen, i)
+	for _, name := range item.People {
+		key := strings.ToLower(name)
+		s.busy[key] = s.busy[key][:len(s.busy[key])-1]
+	}
+	s.tracks[c.slot] = s.tracks[c.slot][:len(s.tracks[c.slot])-1]
+}
+
+// cellCost is the penalty for putting an item in a cell: a big one for every
+// same-track session running at the same time, a small one for empty seats.
+func (s *search) cellCost(item Item, c cell) int {
+	cost := 0
+	if item.Track != "" {
+		for _, b := range s.p.Booked {
+			if strings.EqualFold(b.Track, item.Track) && s.overlapsTime(c.slot, b.Day, b.Start, b.End) {
+				cost += 1000
+			}
+		}
+		for sl, tracks := range s.tracks {
+			if !s.overlaps(sl, c.slot) {
+				continue
+			}
+			for _, t := range tracks {
+				if strings.EqualFold(t, item.Track) {
+					cost += 1000
+				}
+			}
+		}
+	}
+	if room := s.p.Rooms[c.room]; room.Capacity > 0 && item.Audience > 0 {
+		cost += (room.Capacity - item.Audience) / 10
+	}
+	return cost
+}
+
+// clashes counts same-track pairs running at once in the current placement.
+func (s *search) clashes() int {
+	n := 0
+	for i, a := range s.chosen {
+		ta := s.p.Items[i].Track
+		if ta == "" {
+			continue
+		}
+		for j, b := range s.chosen {
+			if j > i && strings.EqualFold(ta, s.p.Items[j].Track) && s.overlaps(a.slot, b.slot) {
+				n++
+			}
+		}
+		for _, bk := range s.p.Booked {
+			if strings.EqualFold(ta, bk.Track) && s.overlapsTime(a.slot, bk.Day, bk.Start, bk.End) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func (s *search) personClash(item Item, slot int) bool {
+	for _, name := range item.People {
+		for _, sl := range s.busy[strings.ToLower(name)] {
+			if s.overlaps(sl, slot) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *search) bookedClash(item Item, slot int) bool {
+	for _, b := range s.p.Booked {
+		if s.overlapsTime(slot, b.Day, b.Start, b.End) && shareName(item.People, b.People) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *search) slotFits(item Item, slot int) bool {
+	sl := s.p.Slots[slot]
+	return item.Minutes <= 0 || minutes(sl.End)-minutes(sl.Start) >= item.Minutes
+}
+
+func (s *search) overlaps(a, b int) bool {
+	sb := s.p.Slots[b]
+	return s.overlapsTime(a, sb.Day, sb.Start, sb.End)
+}
+
+func (s *search) overlapsTime(slot int, day, start, end string) bool {
+	sl := s.p.Slots[slot]
+	return sl.Day == day && sl.Start < end && start < sl.End
+}
+
+func roomFits(r Room, item Item) bool {
+	return r.Capacity <= 0 || item.Audience <= 0 || r.Capacity >= item.Audience
+}
+
+// reason explains why item i was left out of the proposal.
+func (s *search) reason(i int) string {
+	item := s.p.Items[i]
+	long, big, presenter := true, true, true
+	for sl := range s.p.Slots {
+		if !s.slotFits(item, sl) {
+			continue
+		}
+		long = false
+		if !s.bookedClash(item, sl) {
+			presenter = false
+		}
+		for _, r := range s.p.Rooms {
+			if roomFits(r, item) {
+				big = false
+			}
+		}
+	}
+	switch {
+	case len(s.p.Slots) == 0 || len(s.p.Rooms) == 0:
+		return "There are no rooms or session blocks yet"
+	case long:
+		return "Needs " + strconv.Itoa(item.Minutes) + " minutes – longer than every block"
+	case big:
+		return "No room holds " + strconv.Itoa(item.Audience) + " people"
+	case presenter:
+		return "A presenter is already busy in every block long enough"
+	case len(s.candidates[i]) == 0:
+		return "Every room that fits is already booked"
+	}
+	used := make(map[cell]bool)
+	for _, c := range s.best {
+		used[c] = true
+	}
+	for _, c := range s.candidates[i] {
+		if !used[c] {
+			return "A presenter is busy in every free room that fits"
+		}
+	}
+	return "Every room that fits is taken by other pool sessions"
+}
+
+func shareName(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if strings.EqualFold(x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// minutes turns "HH:MM" into minutes after midnight.
+func minutes(hhmm string) int {
+	h, m, _ := strings.Cut(hhmm, ":")
+	hi, _ := strconv.Atoi(h)
+	mi, _ := strconv.Atoi(m)
+	return hi*60 + mi
+}
@@ -0,0 +1,262 @@
+package solver
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func slot(id int, start, end string) Slot {
+	return Slot{ID: id, Day: "2026-03-07", Start: start, End: end}
+}
+
+func TestSolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		p        Problem
+		want     map[int]Assignment // item → where it must go
+		unplaced map[int]string     // item → reason
+	}{
+		{
+			name: "one session per room and slot",
+			p: Problem{
+				Rooms: []Room{{ID: 1}},
+				Slots: []Slot{slot(1, "09:00", "09:45")},
+				Items: []Item{{ID: 1, Audience: 30}, {ID: 2, Audience: 10}},
+			},
+			want:     map[int]Assignment{1: {1, 1, 1}},
+			unplaced: map[int]string{2: "Every room that fits is taken by other pool sessions"},
+		},
+		{
+			name: "booked cells stay booked",
+			p: Problem{
+				Rooms:  []Room{{ID: 1}, {ID: 2}},
+				Slots:  []Slot{slot(1, "09:00", "09:45")},
+				Items:  []Item{{ID: 1}},
+				Booked: []Booking{{RoomID: 1, SlotID: 1, Day: "2026-03-07", Start: "09:00", End: "09:45"}},
+			},
+			want: map[int]Assignment{1: {1, 2, 1}},
+		},
+		{
+			name: "every cell booked",
+			p: Problem{
+				Rooms:  []Room{{ID: 1}},
+				Slots:  []Slot{slot(1, "09:00", "09:45")},
+				Items:  []Item{{ID: 1}},
+				Booked: []Booking{{RoomID: 1, SlotID: 1, Day: "2026-03-07", Start: "09:00", End: "09:45"}},
+			},
+			unplaced: map[int]string{1: "Every room that fits is already booked"},
+		},
+		{
+			name: "room capacity",
+			p: Problem{
+				Rooms: []Room{{ID: 1, Capacity: 20}, {ID: 2, Capacity: 100}},
+				Slots: []Slot{slot(1, "09:00", "09:45")},
+				Items: []Item{{ID: 1, Audience: 80}, {ID: 2, Audience: 200}},
+			},
+			want:     map[int]Assignment{1: {1, 2, 1}},
+			unplaced: map[int]string{2: "No room holds 200 people"},
+		},
+		{
+			name: "smallest room that holds the audience",
+			p: Problem{
+				Rooms: []Room{{ID: 1, Capacity: 100}, {ID: 2, Capacity: 20}, {ID: 3}},
+				Slots: []Slot{slot(1, "09:00", "09:45")},
+				Items: []Item{{ID: 1, Audience: 15}},
+			},
+			want: map[int]Assignment{1: {1, 2, 1}},
+		},
+		{
+			name: "slot length",
+			p: Problem{
+				Rooms: []Room{{ID: 1}},
+				Slots: []Slot{slot(1, "09:00", "09:45"), slot(2, "10:00", "11:30")},
+				Items: []Item{{ID: 1, Minutes: 60}, {ID: 2, Minutes: 120}},
+			},
+			want:     map[int]Assignment{1: {1, 1, 2}},
+			unplaced: map[int]string{2: "Needs 120 minutes – longer than every block"},
+		},
+		{
+			name: "no presenter twice at once",
+			p: Problem{
+				Rooms: []Room{{ID: 1}, {ID: 2}},
+				Slots: []Slot{slot(1, "09:00", "09:45")},
+				Items: []Item{{ID: 1, People: []string{"Jane Doe"}}, {ID: 2, People: []string{"jane doe", "Team 4607"}}},
+			},
+			unplaced: map[int]string{2: "A presenter is busy in every free room that fits"},
+		},
+		{
+			name: "presenter busy in a booked session",
+			p: Problem{
+				Rooms: []Room{{ID: 1}, {ID: 2}},
+				Slots: []Slot{slot(1, "09:00", "09:45"), slot(2, "10:00", "10:45")},
+				Items: []Item{{ID: 1, People: []string{"Team 4607"}}},
+				Booked: []Booking{{RoomID: 1, SlotID: 2, Day: "2026-03-07", Start: "10:00", End: "10:45",
+					People: []string{"Team 4607"}}},
+			},
+			want: map[int]Assignment{1: {1, 1, 1}},
+		},
+		{
+			name: "presenter busy in every slot",
+			p: Problem{
+				Rooms: []Room{{ID: 1}, {ID: 2}},
+				Slots: []Slot{slot(1, "09:00", "09:45")},
+				Items: []Item{{ID: 1, People: []string{"Team 4607"}}},
+				Booked: []Booking{{RoomID: 1, SlotID: 1, Day: "2026-03-07", Start: "09:00", End: "09:45",
+					People: []string{"Team 4607"}}},
+			},
+			unplaced: map[int]string{1: "A presenter is already busy in every block long enough"},
+		},
+		{
+			name: "same time on another day is no clash",
+			p: Problem{
+				Rooms: []Room{{ID: 1}},
+				Slots: []Slot{slot(1, "09:00", "09:45"), {ID: 2, Day: "2026-03-08", Start: "09:00", End: "09:45"}},
+				Items: []Item{{ID: 1, People: []string{"Jane Doe"}}, {ID: 2, People: []string{"Jane Doe"}}},
+			},
+			want: map[int]Assignment{1: {1, 1, 1}, 2: {2, 1, 2}},
+		},
+		{
+			name: "no rooms",
+			p: Problem{
+				Slots: []Slot{slot(1, "09:00", "09:45")},
+				Items: []Item{{ID: 1}},
+			},
+			unplaced: map[int]string{1: "There are no rooms or session blocks yet"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Solve(tt.p)
+			checkRules(t, tt.p, res)
+
+			got := make(map[int]Assignment)
+			for _, a := range res.Assignments {
+				got[a.ItemID] = a
+			}
+			for id, want := range tt.want {
+				if got[id] != want {
+					t.Errorf("item %d: got %+v, want %+v", id, got[id], want)
+				}
+			}
+			reasons := make(map[int]string)
+			for _, u := range res.Unplaced {
+				reasons[u.ItemID] = u.Reason
+			}
+			for id, want := range tt.unplaced {
+				if reasons[id] != want {
+					t.Errorf("item %d: unplaced because %q, want %q", id, reasons[id], want)
+				}
+			}
+			if len(res.Assignments)+len(res.Unplaced) != len(tt.p.Items) {
+				t.Errorf("%d placed + %d unplaced, want %d items", len(res.Assignments), len(res.Unplaced), len(tt.p.Items))
+			}
+		})
+	}
+}
+
+func TestSolveKeepsTracksApart(t *testing.T) {
+	p := Problem{
+		Rooms: []Room{{ID: 1}, {ID: 2}},
+		Slots: []Slot{slot(1, "09:00", "09:45"), slot(2, "10:00", "10:45")},
+		Items: []Item{{ID: 1, Track: "Programming"}, {ID: 2, Track: "programming"}},
+	}
+	res := Solve(p)
+	checkRules(t, p, res)
+	if len(res.Assignments) != 2 {
+		t.Fatalf("placed %d, want 2", len(res.Assignments))
+	}
+	if res.Assignments[0].SlotID == res.Assignments[1].SlotID || res.TrackClashes != 0 {
+		t.Errorf("same-track sessions run together: %+v, %d clashes", res.Assignments, res.TrackClashes)
+	}
+}
+
+// TestSolveBudget gives Solve more items than cells, all sharing a presenter
+// pool, so no search could try every combination, and checks it stops at the
+// budget with a proposal that still keeps the rules.
+func TestSolveBudget(t *testing.T) {
+	var p Problem
+	for i := 1; i <= 6; i++ {
+		p.Rooms = append(p.Rooms, Room{ID: i, Capacity: 20 * i})
+	}
+	for i := 0; i < 8; i++ {
+		start := 8*60 + i*60
+		p.Slots = append(p.Slots, slot(i+1, clock(start), clock(start+45)))
+	}
+	for i := 1; i <= 80; i++ {
+		p.Items = append(p.Items, Item{
+			ID:       i,
+			People:   []string{"Team " + strconv.Itoa(i%9), "Mentor " + strconv.Itoa(i%7)},
+			Audience: 10 + (i*7)%100,
+			Track:    "Track " + strconv.Itoa(i%4),
+		})
+	}
+
+	s := newSearch(p)
+	s.order = make([]int, len(p.Items))
+	for i := range s.order {
+		s.order[i] = i
+	}
+	s.run(0, 0, 0)
+	if s.nodes != searchBudget {
+		t.Fatalf("search looked at %d partial schedules, want it cut off at %d", s.nodes, searchBudget)
+	}
+
+	res := Solve(p)
+	checkRules(t, p, res)
+	if len(res.Assignments) == 0 {
+		t.Error("nothing placed before the budget ran out")
+	}
+}
+
+// checkRules fails t for every hard rule res breaks.
+func checkRules(t *testing.T, p Problem, res Result) {
+	t.Helper()
+	rooms := make(map[int]Room)
+	for _, r := range p.Rooms {
+		rooms[r.ID] = r
+	}
+	slots := make(map[int]Slot)
+	for _, sl := range p.Slots {
+		slots[sl.ID] = sl
+	}
+	items := make(map[int]Item)
+	for _, it := range p.Items {
+		items[it.ID] = it
+	}
+
+	type placed struct {
+		slot   Slot
+		people []string
+	}
+	var all []placed
+	cells := make(map[[2]int]bool)
+	for _, b := range p.Booked {
+		cells[[2]int{b.RoomID, b.SlotID}] = true
+		all = append(all, placed{Slot{Day: b.Day, Start: b.Start, End: b.End}, b.People})
+	}
+	for _, a := range res.Assignments {
+		it, r, sl := items[a.ItemID], rooms[a.RoomID], slots[a.SlotID]
+		if cells[[2]int{a.RoomID, a.SlotID}] {
+			t.Errorf("item %d: room %d, slot %d already has a session", a.ItemID, a.RoomID, a.SlotID)
+		}
+		cells[[2]int{a.RoomID, a.SlotID}] = true
+		if r.Capacity > 0 && it.Audience > r.Capacity {
+			t.Errorf("item %d: %d people in room %d with %d seats", a.ItemID, it.Audience, a.RoomID, r.Capacity)
+		}
+		if it.Minutes > 0 && minutes(sl.End)-minutes(sl.Start) < it.Minutes {
+			t.Errorf("item %d: needs %d minutes, slot %d is %s–%s", a.ItemID, it.Minutes, a.SlotID, sl.Start, sl.End)
+		}
+		for _, o := range all {
+			if o.slot.Day == sl.Day && o.slot.Start < sl.End && sl.Start < o.slot.End && shareName(it.People, o.people) {
+				t.Errorf("item %d: %s presents twice at %s", a.ItemID, strings.Join(it.People, ", "), sl.Start)
+			}
+		}
+		all = append(all, placed{sl, it.People})
+	}
+}
+
+func clock(m int) string {
+	return strconv.Itoa(m/60/10) + strconv.Itoa(m/60%10) + ":" + strconv.Itoa(m%60/10) + strconv.Itoa(m%10)
+}
@@ -8,40 +8,110 @@
       minuteIncrement: 5,
       allowInput: true,
     });
+    updateGaps();
   });
 
-  // Your existing auto-cascade script (unchanged)
+  // Your existing auto-cascade script – each day has its own table,
+  // so the cascade restarts per table
   function updateSchedule() {
     const length = parseInt(document.getElementById('length').value) || 80;
     const breakM = parseInt(document.getElementById('break').value) || 10;
-    let prevEnd = null;
-    document.querySelectorAll('table tr').forEach((row, i) => {
-      if (i === 0) return;
-      const startInput = row.querySelector('input[name^="start_"]');
-      const endInput = row.querySelector('input[name^="end_"]');
-      if (!startInput) return;
-
-      let startTime = startInput.value;
-      if (!startTime && prevEnd) {
-        const d = new Date(`2020-01-01 ${prevEnd}:00`);
-        d.setMinutes(d.getMinutes() + breakM);
-        startTime = d.toTimeString().slice(0,5);
-        startInput.value = startTime;
-        startInput._flatpickr.setDate(startTime, true);
-      }
-
-      if (startTime) {
-        const [h, m] = startTime.split(':').map(Number);
-        const d = new Date();
-        d.setHours(h, m, 0, 0);
-        d.setMinutes(d.getMinutes() + length);
-        const endH = String(d.getHours()).padStart(2,'0');
-        const endM = String(d.getMinutes()).padStart(2,'0');
-        if (!endInput.value || endInput.value === endInput.defaultValue) {
-          endInput.value = endH + ':' + endM;
-          if (endInput._flatpickr) endInput._flatpickr.setDate(endH + ':' + endM, true);
+    document.querySelectorAll('.day-section table').forEach(table => {
+      let prevEnd = null;
+      let gap = breakM;
+      table.querySelectorAll('tr').forEach((row, i) => {
+        if (i === 0) return;
+        const gapInput = row.querySelector('.gap-input');
+        if (gapInput) {
+          gap = gapInput.value === '' ? breakM : parseInt(gapInput.value);
+          return;
         }
-        prevEnd = endH + ':' + endM;
-      }
+        const startInput = row.querySelector('input[name^="start_"]');
+        const endInput = row.querySelector('input[name^="end_"]');
+        if (!startInput) return;
+
+        let startTime = startInput.value;
+        if (!startTime && prevEnd) {
+          const d = new Date(`2020-01-01 ${prevEnd}:00`);
+          d.setMinutes(d.getMinutes() + gap);
+          startTime = d.toTimeString().slice(0,5);
+          startInput.value = startTime;
+          startInput._flatpickr.setDate(startTime, true);
+        }
+
+        if (startTime) {
+          const [h, m] = startTime.split(':').map(Number);
+          const d = new Date();
+          d.setHours(h, m, 0, 0);
+          d.setMinutes(d.getMinutes() + length);
+          const endH = String(d.getHours()).padStart(2,'0');
+          const endM = String(d.getMinutes()).padStart(2,'0');
+          if (!endInput.value || endInput.value === endInput.defaultValue) {
+            endInput.value = endH + ':' + endM;
+            if (endInput._flatpickr) endInput._flatpickr.setDate(endH + ':' + endM, true);
+          }
+          prevEnd = endH + ':' + endM;
+        }
+      });
+    });
+    updateGaps();
+  }
+
+  const isClock = t => /^\d\d:\d\d$/.test(t);
+  const toMin = t => { const [h, m] = t.split(':').map(Number); return h * 60 + m; };
+  const toClock = n => String(Math.floor(n / 60) % 24).padStart(2, '0') + ':' + String(n % 60).padStart(2, '0');
+
+  function setTime(input, value) {
+    input.value = value;
+    if (input._flatpickr) input._flatpickr.setDate(value, false);
+  }
+
+  // Keep in step with gapLabel in web/blocks.go
+  function gapLabel(n) {
+    if (n < 0) return `Overlaps by ${-n} min`;
+    if (n === 0) return 'Back to back';
+    if (n < 30) return `${n} min passing period`;
+    return `${n} min break`;
+  }
+
+  // Relabel the gap row above each block from the times on screen
+  function updateGaps() {
+    document.querySelectorAll('.day-section table').forEach(table => {
+      let prevEnd = null;
+      let gapRow = null;
+      table.querySelectorAll('tbody tr').forEach(row => {
+        if (row.classList.contains('gap')) { gapRow = row; return; }
+        const startInput = row.querySelector('input[name^="start_"]');
+        const endInput = row.querySelector('input[name^="end_"]');
+        if (!startInput) return;
+        if (gapRow && prevEnd !== null) {
+          const ok = isClock(prevEnd) && isClock(startInput.value);
+          const n = ok ? toMin(startInput.value) - toMin(prevEnd) : 0;
+          gapRow.querySelector('.gap-input').value = ok && n >= 0 ? n : '';
+          gapRow.querySelector('.gap-label').textContent = ok ? gapLabel(n) : '';
+          gapRow.classList.toggle('overlap', ok && n < 0);
+        }
+        prevEnd = endInput.value;
+        gapRow = null;
+      });
     });
-  }
\ No newline at end of file
+  }
+
+  // A changed gap moves the block below it, and everything after it on that
+  // day, by the same amount
+  function shiftFromGap(gapInput) {
+    const gapRow = gapInput.closest('tr');
+    const prev = gapRow.previousElementSibling;
+    const prevEnd = prev && prev.querySelector('input[name^="end_"]').value;
+    const first = gapRow.nextElementSibling.querySelector('input[name^="start_"]');
+    const gap = parseInt(gapInput.value);
+    if (!isClock(prevEnd) || !isClock(first.value) || isNaN(gap) || gap < 0) return;
+
+    const delta = toMin(prevEnd) + gap - toMin(first.value);
+    for (let row = gapRow.nextElementSibling; row; row = row.nextElementSibling) {
+      row.querySelectorAll('input[name^="start_"], input[name^="end_"]').forEach(input => {
+        if (isClock(input.value)) setTime(input, toClock(toMin(input.value) + delta));
+      });
+    }
+    updateGaps();
+  }
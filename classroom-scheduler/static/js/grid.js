@@ -0,0 +1,75 @@
+// Drag-and-drop for /grid – each drop is saved through /api/move
+document.addEventListener("DOMContentLoaded", function () {
+  const message = document.getElementById("grid-message");
+  const conflicts = document.getElementById("grid-conflicts");
+  let dragged = null;
+
+  document.querySelectorAll(".card").forEach(bindCard);
+
+  function bindCard(card) {
+    card.addEventListener("dragstart", e => {
+      dragged = card;
+      card.classList.add("dragging");
+      e.dataTransfer.setData("text/plain", card.dataset.id);
+    });
+    card.addEventListener("dragend", () => {
+      card.classList.remove("dragging");
+      dragged = null;
+    });
+  }
+
+  document.querySelectorAll(".drop").forEach(cell => {
+    cell.addEventListener("dragover", e => {
+      e.preventDefault();
+      cell.classList.add("over");
+    });
+    cell.addEventListener("dragleave", () => cell.classList.remove("over"));
+    cell.addEventListener("drop", e => {
+      e.preventDefault();
+      cell.classList.remove("over");
+      if (!dragged || dragged.parentElement === cell) return;
+      move(dragged, cell);
+    });
+  });
+
+  function move(card, target) {
+    const source = card.parentElement;
+    fetch("/api/move", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({
+        session_id: parseInt(card.dataset.id),
+        classroom_id: parseInt(target.dataset.cid),
+        block_id: parseInt(target.dataset.block),
+      }),
+    })
+      .then(r => r.json())
+      .then(res => {
+        if (!res.ok) {
+          show(res.error || "Move failed", true);
+          return;
+        }
+        // Row spans change with multi-block sessions – redraw the grid
+        if (card.dataset.span > 1) {
+          location.reload();
+          return;
+        }
+        if (res.swapped_id) {
+          const other = target.querySelector(`.card[data-id="${res.swapped_id}"]`);
+          if (other) source.appendChild(other);
+        }
+        target.appendChild(card);
+        // Placed sessions take the block's times again
+        card.classList.remove("override");
+        conflicts.hidden = res.conflicts === 0;
+        conflicts.querySelector("span").textContent = res.conflicts;
+        show("Saved", false);
+      })
+      .catch(() => show("Couldn't reach the server – nothing was saved", true));
+  }
+
+  function show(text, isError) {
+    message.textContent = text;
+    message.className = isError ? "error" : "";
+  }
+});
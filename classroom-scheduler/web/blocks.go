@@ -4,18 +4,77 @@ package web
 import (
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// BlockDay is one day's table on /blocks. Gaps[i] is the time between
+// block i-1 and block i (zero for the first).
+type BlockDay struct {
+	Index  int
+	Day    string
+	Blocks []Block
+	Gaps   []Gap
+}
+
+// Gap is the time between two blocks. Label is "" when either time is unreadable.
+type Gap struct {
+	Minutes int
+	Label   string
+}
+
 func BlocksHandler(w http.ResponseWriter, r *http.Request) {
     mu.RLock()
+    p := currentBlockPlan()
+    mu.RUnlock()
+
+    renderBlocks(w, p, r.URL.Query().Get("saved"))
+}
+
+// currentBlockPlan is the saved schedule as a plan – caller holds mu.
+func currentBlockPlan() blockPlan {
+    p := blockPlan{
+        NumClassrooms: len(classroomsCache),
+        SessionLength: sessionLengthMinutes,
+        BreakMinutes:  breakMinutes,
+        Template:      currentDayTemplate(),
+    }
+    for i, d := range scheduleDays() {
+        bd := BlockDay{Index: i, Day: d}
+        for _, b := range blocksCache {
+            if b.Day == d {
+                bd.Blocks = append(bd.Blocks, b)
+            }
+        }
+        p.Days = append(p.Days, bd)
+    }
+    return p
+}
+
+// renderBlocks draws /blocks from a plan – the saved schedule, or what the
+// admin typed along with p.Errors.
+func renderBlocks(w http.ResponseWriter, p blockPlan, flash string) {
+    days := p.Days
+    for i := range days {
+        days[i].Gaps = blockGaps(days[i].Blocks)
+    }
+    count := 0
+    for _, d := range days {
+        count += len(d.Blocks)
+    }
     data := struct {
-        Blocks               []Block
+        Days                 []BlockDay
+        Errors               map[string]string
+        Template             DayTemplate
+        TemplateErrors       map[string]string
         BlockCount           int
         NumClassrooms        int
         DefaultSessionLength int
         BreakMinutes         int
+        Kinds                []string
 
         // Layout fields
         Active    string
@@ -24,23 +83,55 @@ func BlocksHandler(w http.ResponseWriter, r *http.Request) {
         ExtraCSS  []string
         Flash     string
     }{
-        Blocks:               blocksCache,
-        BlockCount:           len(blocksCache),
-        NumClassrooms:        len(classroomsCache),
-        DefaultSessionLength: sessionLengthMinutes,
-        BreakMinutes:         breakMinutes,
+        Days:                 days,
+        Errors:               p.Errors,
+        Template:             p.Template,
+        TemplateErrors:       p.TemplateErrors,
+        BlockCount:           count,
+        NumClassrooms:        p.NumClassrooms,
+        DefaultSessionLength: p.SessionLength,
+        BreakMinutes:         p.BreakMinutes,
+        Kinds:                blockKinds,
 
         Active:    "blocks",
         PageTitle: "Schedule Blocks",
-        Year:      time.Now().Year(),
+        Year:      eventNow().Year(),
         ExtraCSS:  []string{"blocks.css"},           // your custom CSS
-        Flash:     r.URL.Query().Get("saved"),       // optional success message
+        Flash:     flash,                            // optional success message
     }
-    mu.RUnlock()
-
     RenderTemplate(w, "blocks.html", data)
 }
 
+// blockPlan is a parsed /blocks form – nothing is applied until it is
+// confirmed (or has no impact on existing sessions). Days keeps the rows in
+// the order they were typed; Errors is keyed "{day}_{row}" like the form,
+// or "{day}" for the day itself.
+// Template fills the day generator form above the tables.
+type blockPlan struct {
+	NumClassrooms  int
+	SessionLength  int
+	BreakMinutes   int
+	Blocks         []Block
+	Days           []BlockDay
+	Errors         map[string]string
+	Template       DayTemplate
+	TemplateErrors map[string]string
+}
+
+// Impact is one line of the dry-run shown before a destructive blocks save.
+type Impact struct {
+	What    string // "Room removed", "Unscheduled" or "Moved"
+	Room    string
+	Session string
+	Detail  string
+}
+
+// FormField re-posts what the admin typed from the preview page.
+type FormField struct {
+	Name  string
+	Value string
+}
+
 func BlocksSaveHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.NotFound(w, r)
@@ -48,85 +139,409 @@ func BlocksSaveHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	r.ParseForm()
 
+	mu.Lock()
+	plan := parseBlockPlan(r)
+	if len(plan.Errors) > 0 {
+		mu.Unlock()
+		renderBlocks(w, plan, "")
+		return
+	}
+	impacts := planImpact(plan)
+	if len(impacts) > 0 && r.FormValue("confirm") != "1" {
+		mu.Unlock()
+		renderBlocksPreview(w, r, impacts)
+		return
+	}
+	var orphaned int
+	err := editSchedule(r.Context(), func(sc *Schedule) error {
+		orphaned = applyBlockPlan(sc, plan)
+		sc.Settings = map[string]string{
+			"session_length_minutes": strconv.Itoa(plan.SessionLength),
+			"break_minutes":          strconv.Itoa(plan.BreakMinutes),
+		}
+		return nil
+	})
+	if err != nil {
+		mu.Unlock()
+		renderBlocks(w, plan, saveFailed(err))
+		return
+	}
+	sessionLengthMinutes, breakMinutes = plan.SessionLength, plan.BreakMinutes
+	mu.Unlock()
+
+	log.Printf("Saved: %d classrooms, %d blocks, %d sessions unscheduled", plan.NumClassrooms, len(plan.Blocks), orphaned)
+	msg := "Schedule saved"
+	if orphaned > 0 {
+		msg += " – " + strconv.Itoa(orphaned) + " session(s) are listed under Unscheduled on Edit Sessions"
+	}
+	http.Redirect(w, r, "/blocks?saved="+url.QueryEscape(msg), http.StatusSeeOther)
+}
+
+// parseBlockPlan reads the /blocks form – caller holds mu.
+func parseBlockPlan(r *http.Request) blockPlan {
+	p := blockPlan{SessionLength: sessionLengthMinutes, BreakMinutes: breakMinutes, Errors: make(map[string]string), Template: currentDayTemplate()}
+
 	// Classrooms count
-	numClassrooms, _ := strconv.Atoi(r.FormValue("num_classrooms"))
-	if numClassrooms < 1 {
-		numClassrooms = 1
+	p.NumClassrooms, _ = strconv.Atoi(r.FormValue("num_classrooms"))
+	if p.NumClassrooms < 1 {
+		p.NumClassrooms = 1
 	}
-	if numClassrooms > 30 {
-		numClassrooms = 30
+	if p.NumClassrooms > 30 {
+		p.NumClassrooms = 30
 	}
 
 	// Session length & break
-	if v := r.FormValue("session_length"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n >= 20 && n <= 300 {
-			sessionLengthMinutes = n
-			saveSetting("session_length_minutes", v)
+	if n, err := strconv.Atoi(r.FormValue("session_length")); err == nil && n >= 20 && n <= 300 {
+		p.SessionLength = n
+	}
+	if n, err := strconv.Atoi(r.FormValue("break_minutes")); err == nil && n >= 0 && n <= 120 {
+		p.BreakMinutes = n
+	}
+
+	// Build new blocks, one table per day
+	var blocks []Block
+	for di := 0; r.Form.Has("day_" + strconv.Itoa(di)); di++ {
+		dayKey := strconv.Itoa(di)
+		day := r.FormValue("day_" + dayKey)
+		if day != "" && !validDate(day) {
+			p.Errors[dayKey] = day + " is not a date (YYYY-MM-DD) – check the event's dates"
+		}
+
+		// Blocks count for this day
+		count, _ := strconv.Atoi(r.FormValue("block_count_" + dayKey))
+		if count < 0 {
+			count = 0
 		}
+		if count > 20 {
+			count = 20
+		}
+
+		bd := BlockDay{Index: di, Day: day}
+		var prevEnd time.Time
+		for i := 0; i < count; i++ {
+			idx := dayKey + "_" + strconv.Itoa(i+1)
+			startStr := strings.TrimSpace(r.FormValue("start_" + idx))
+			endStr := strings.TrimSpace(r.FormValue("end_" + idx))
+
+			// Blank times follow on from the previous block after its own gap
+			// (or the default break); typed ones must parse
+			var startTime time.Time
+			startOK, endOK := true, true
+			if startStr != "" {
+				t, err := time.Parse("15:04", startStr)
+				startTime, startOK = t, err == nil
+			} else if i == 0 {
+				startTime = time.Date(0, 1, 1, 8, 0, 0, 0, time.UTC)
+			} else {
+				gap := p.BreakMinutes
+				if n, err := strconv.Atoi(r.FormValue("gap_" + idx)); err == nil && n >= 0 && n <= 240 {
+					gap = n
+				}
+				startTime = prevEnd.Add(time.Minute * time.Duration(gap))
+			}
+
+			endTime := startTime.Add(time.Minute * time.Duration(p.SessionLength))
+			if endStr != "" {
+				t, err := time.Parse("15:04", endStr)
+				endTime, endOK = t, err == nil
+			}
+
+			kind := r.FormValue("kind_" + idx)
+			if !validBlockKind(kind) {
+				kind = BlockSession
+			}
+			id, _ := strconv.Atoi(r.FormValue("id_" + idx))
+			b := Block{
+				ID:        id,
+				Day:       day,
+				StartTime: startTime.Format("15:04"),
+				EndTime:   endTime.Format("15:04"),
+				Kind:      kind,
+			}
+			if b.Spanning() {
+				b.Title = strings.TrimSpace(r.FormValue("title_" + idx))
+				b.Location = strings.TrimSpace(r.FormValue("location_" + idx))
+			}
+			switch {
+			case !startOK:
+				b.StartTime = startStr
+				p.Errors[idx] = "Start time must be HH:MM"
+			case !endOK:
+				b.EndTime = endStr
+				p.Errors[idx] = "End time must be HH:MM"
+			case b.EndTime <= b.StartTime:
+				p.Errors[idx] = "End time must be after the start time"
+			}
+			bd.Blocks = append(bd.Blocks, b)
+			prevEnd = endTime
+		}
+		checkBlockOverlaps(bd, p.Errors)
+		p.Days = append(p.Days, bd)
+		blocks = append(blocks, bd.Blocks...)
+	}
+	if len(blocks) == 0 {
+		blocks = append(blocks, Block{
+			Day:       r.FormValue("day_0"),
+			StartTime: "08:00",
+			EndTime:   time.Date(0, 1, 1, 8, p.SessionLength, 0, 0, time.UTC).Format("15:04"),
+			Kind:      BlockSession,
+		})
 	}
-	if v := r.FormValue("break_minutes"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n >= 0 && n <= 120 {
-			breakMinutes = n
-			saveSetting("break_minutes", v)
+	sortBlocks(blocks)
+
+	// Existing blocks keep their ID; new rows get fresh ones
+	nextID := 1
+	for _, b := range blocksCache {
+		if b.ID >= nextID {
+			nextID = b.ID + 1
+		}
+	}
+	seen := make(map[int]bool)
+	for i := range blocks {
+		if blocks[i].ID == 0 || seen[blocks[i].ID] || findBlock(blocks[i].ID) == nil {
+			blocks[i].ID = nextID
+			nextID++
 		}
+		seen[blocks[i].ID] = true
 	}
+	p.Blocks = blocks
+	return p
+}
 
-	// Blocks count
-	count, _ := strconv.Atoi(r.FormValue("block_count"))
-	if count < 1 {
-		count = 1
+// planImpact lists the rooms and real sessions (ones with a title or
+// presenter) a plan would drop, unschedule or move – caller holds mu.
+func planImpact(p blockPlan) []Impact {
+	byID := make(map[int]Block, len(p.Blocks))
+	for _, b := range p.Blocks {
+		byID[b.ID] = b
 	}
-	if count > 20 {
-		count = 20
+
+	var impacts []Impact
+	for _, c := range sortedClassrooms(classroomsCache) {
+		real := 0
+		for _, s := range sessionsCache[c.ID] {
+			if s.Title == "" && s.Presenter == "" {
+				continue
+			}
+			real++
+			if c.ID > p.NumClassrooms {
+				impacts = append(impacts, Impact{"Unscheduled", c.Name, sessionLabel(s), "its room is being removed"})
+				continue
+			}
+			nb, ok := byID[s.BlockID]
+			switch {
+			case !ok:
+				impacts = append(impacts, Impact{"Unscheduled", c.Name, sessionLabel(s), "its block " + timeRange("", s.StartTime, s.EndTime) + " is being removed"})
+			case nb.Spanning():
+				impacts = append(impacts, Impact{"Unscheduled", c.Name, sessionLabel(s), "its block becomes " + nb.Label()})
+			default:
+				newRun := blockRun(p.Blocks, s.BlockID, s.Span)
+				newEnd := newRun[len(newRun)-1].EndTime
+				if len(newRun) < s.Span {
+					impacts = append(impacts, Impact{"Shortened", c.Name, sessionLabel(s),
+						"only " + strconv.Itoa(len(newRun)) + " of its " + strconv.Itoa(s.Span) + " blocks stay back to back"})
+				}
+				if !sessionOverridden(s) && (nb.Day != s.Day || nb.StartTime != s.StartTime || newEnd != s.EndTime) {
+					impacts = append(impacts, Impact{"Moved", c.Name, sessionLabel(s),
+						timeRange(s.Day, s.StartTime, s.EndTime) + " → " + timeRange(nb.Day, nb.StartTime, newEnd)})
+				}
+			}
+		}
+		if c.ID > p.NumClassrooms && (real > 0 || c.Name != "Classroom "+strconv.Itoa(c.ID)) {
+			impacts = append(impacts, Impact{"Room removed", c.Name, "", strconv.Itoa(real) + " session(s) move to Unscheduled"})
+		}
 	}
+	return impacts
+}
 
-	mu.Lock()
-	// Resize classrooms
+// applyBlockPlan resizes sc's classrooms and replaces its blocks,
+// unscheduling sessions that lost their room or block. Returns how many.
+func applyBlockPlan(sc *Schedule, p blockPlan) int {
+	// Resize classrooms; sessions in removed rooms become unscheduled
+	orphaned := 0
 	newClassrooms := make(map[int]*Classroom)
-	for i := 1; i <= numClassrooms; i++ {
-		if old, ok := classroomsCache[i]; ok {
+	for i := 1; i <= p.NumClassrooms; i++ {
+		if old, ok := sc.Classrooms[i]; ok {
 			newClassrooms[i] = old
 		} else {
 			newClassrooms[i] = &Classroom{ID: i, Name: "Classroom " + strconv.Itoa(i)}
 		}
 	}
-	classroomsCache = newClassrooms
+	for cid, list := range sc.Sessions {
+		if cid <= p.NumClassrooms {
+			continue
+		}
+		for _, s := range list {
+			if !s.isEmpty() {
+				s.ClassroomID = 0
+				s.BlockID = 0
+				sc.Sessions[0] = append(sc.Sessions[0], s)
+				orphaned++
+			}
+		}
+		delete(sc.Sessions, cid)
+	}
+	sc.Classrooms = newClassrooms
 
-	// Build new blocks
-	blocksCache = make([]Block, count)
-	var prevEnd time.Time
-	for i := 0; i < count; i++ {
-		idx := i + 1
-		startStr := r.FormValue("start_" + strconv.Itoa(idx))
-		endStr := r.FormValue("end_" + strconv.Itoa(idx))
+	orphaned += rebindSessions(sc, p.Blocks)
+	sc.Blocks = p.Blocks
+	return orphaned
+}
 
-		var startTime time.Time
-		if startStr != "" {
-			startTime, _ = time.Parse("15:04", startStr)
-		} else if i == 0 {
-			startTime = time.Date(0, 1, 1, 8, 0, 0, 0, time.UTC)
-		} else {
-			startTime = prevEnd.Add(time.Minute * time.Duration(breakMinutes))
+func renderBlocksPreview(w http.ResponseWriter, r *http.Request, impacts []Impact) {
+	var fields []FormField
+	for name, values := range r.PostForm {
+		if name == "confirm" {
+			continue
 		}
+		for _, v := range values {
+			fields = append(fields, FormField{name, v})
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+
+	data := struct {
+		Impacts []Impact
+		Fields  []FormField
 
-		endTime := startTime.Add(time.Minute * time.Duration(sessionLengthMinutes))
-		if endStr != "" {
-			if t, err := time.Parse("15:04", endStr); err == nil {
-				endTime = t
+		Active    string
+		PageTitle string
+		Year      int
+		ExtraCSS  []string
+		Flash     string
+	}{
+		Impacts: impacts,
+		Fields:  fields,
+
+		Active:    "blocks",
+		PageTitle: "Confirm Schedule Changes",
+		Year:      eventNow().Year(),
+		ExtraCSS:  []string{"blocks.css"},
+		Flash:     "",
+	}
+	RenderTemplate(w, "blocks_preview.html", data)
+}
+
+// sortedClassrooms returns rooms ordered by ID.
+func sortedClassrooms(rooms map[int]*Classroom) []*Classroom {
+	list := make([]*Classroom, 0, len(rooms))
+	for _, c := range rooms {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// checkBlockOverlaps flags rows of one day that start before an earlier
+// block ends. Rows that already have an error are left out.
+func checkBlockOverlaps(bd BlockDay, errs map[string]string) {
+	type row struct {
+		key string
+		n   int
+		b   Block
+	}
+	var rows []row
+	for i, b := range bd.Blocks {
+		key := strconv.Itoa(bd.Index) + "_" + strconv.Itoa(i+1)
+		if errs[key] == "" {
+			rows = append(rows, row{key, i + 1, b})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].b.StartTime < rows[j].b.StartTime })
+	for i := 1; i < len(rows); i++ {
+		// prev is whichever earlier block ends last
+		prev := rows[0]
+		for _, r := range rows[:i] {
+			if r.b.EndTime > prev.b.EndTime {
+				prev = r
 			}
 		}
+		if cur := rows[i]; cur.b.StartTime < prev.b.EndTime {
+			errs[cur.key] = "Overlaps Session " + strconv.Itoa(prev.n) + " (" + prev.b.StartTime + "–" + prev.b.EndTime + ")"
+		}
+	}
+}
 
-		blocksCache[i] = Block{
-			ID:        idx,
-			StartTime: startTime.Format("15:04"),
-			EndTime:   endTime.Format("15:04"),
+// blockGaps measures the time between each block and the one above it.
+func blockGaps(blocks []Block) []Gap {
+	gaps := make([]Gap, len(blocks))
+	for i := 1; i < len(blocks); i++ {
+		end, start := blocks[i-1].EndTime, blocks[i].StartTime
+		if validClock(end) && validClock(start) {
+			n := clockMinutes(start) - clockMinutes(end)
+			gaps[i] = Gap{Minutes: n, Label: gapLabel(n)}
 		}
-		prevEnd = endTime
 	}
-	mu.Unlock()
+	return gaps
+}
+
+// gapLabel – keep in step with gapLabel in static/js/blocks.js.
+func gapLabel(n int) string {
+	switch {
+	case n < 0:
+		return "Overlaps by " + strconv.Itoa(-n) + " min"
+	case n == 0:
+		return "Back to back"
+	case n < 30:
+		return strconv.Itoa(n) + " min passing period"
+	default:
+		return strconv.Itoa(n) + " min break"
+	}
+}
+
+func timeRange(day, start, end string) string {
+	if day != "" {
+		return dayLabel(day) + " " + displayClock(start) + "–" + displayClock(end)
+	}
+	return displayClock(start) + "–" + displayClock(end)
+}
 
-	saveClassroomsToDB()
-	saveBlocksToDB()
-	log.Printf("Saved: %d classrooms, %d blocks", numClassrooms, count)
-	http.Redirect(w, r, "/blocks", http.StatusSeeOther)
-}
\ No newline at end of file
+func validBlockKind(kind string) bool {
+	for _, k := range blockKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// rebindSessions moves every session of sc along with its block before
+// blocks replaces sc.Blocks. Sessions on their blocks' times follow new times;
+// custom times stay put. Multi-block sessions lose the blocks that no longer
+// follow on. Sessions whose block is gone (or became an
+// all-rooms block) become unscheduled. Returns how many – caller holds mu.
+func rebindSessions(sc *Schedule, blocks []Block) int {
+	byID := make(map[int]Block, len(blocks))
+	for _, b := range blocks {
+		byID[b.ID] = b
+	}
+	orphaned := 0
+	for cid, list := range sc.Sessions {
+		if cid == 0 {
+			continue
+		}
+		var kept []Session
+		for _, s := range list {
+			nb, ok := byID[s.BlockID]
+			if !ok || nb.Spanning() {
+				if !s.isEmpty() {
+					s.ClassroomID = 0
+					s.BlockID = 0
+					sc.Sessions[0] = append(sc.Sessions[0], s)
+					orphaned++
+				}
+				continue
+			}
+			newRun := blockRun(blocks, s.BlockID, s.Span)
+			if !sc.sessionOverridden(s) {
+				s.StartTime = nb.StartTime
+				s.EndTime = newRun[len(newRun)-1].EndTime
+			}
+			s.Span = min(s.Span, len(newRun))
+			s.Day = nb.Day
+			kept = append(kept, s)
+		}
+		sc.Sessions[cid] = kept
+	}
+	return orphaned
+}
@@ -0,0 +1,142 @@
+package web
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseBlockPlanRows(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		form       url.Values
+		wantBlocks []string // kind start-end of each row, in form order
+		wantErrors map[string]string
+	}{
+		{
+			name: "typed rows",
+			form: url.Values{
+				"day_0": {"2026-03-07"}, "block_count_0": {"2"},
+				"start_0_1": {"09:00"}, "end_0_1": {"09:45"},
+				"start_0_2": {"10:00"}, "end_0_2": {"10:45"},
+			},
+			wantBlocks: []string{"session 09:00-09:45", "session 10:00-10:45"},
+			wantErrors: map[string]string{},
+		},
+		{
+			name: "blank times follow on after the row's gap",
+			form: url.Values{
+				"day_0": {"2026-03-07"}, "block_count_0": {"3"}, "session_length": {"40"}, "break_minutes": {"10"},
+				"start_0_1": {"09:00"},
+				"gap_0_2":   {"25"},
+			},
+			wantBlocks: []string{"session 09:00-09:40", "session 10:05-10:45", "session 10:55-11:35"},
+			wantErrors: map[string]string{},
+		},
+		{
+			name: "start that is not a time",
+			form: url.Values{
+				"day_0": {"2026-03-07"}, "block_count_0": {"2"},
+				"start_0_1": {"9am"}, "end_0_1": {"09:45"},
+				"start_0_2": {"10:00"}, "end_0_2": {"10:45"},
+			},
+			wantBlocks: []string{"session 9am-09:45", "session 10:00-10:45"},
+			wantErrors: map[string]string{"0_1": "Start time must be HH:MM"},
+		},
+		{
+			name: "end that is not a time",
+			form: url.Values{
+				"day_0": {"2026-03-07"}, "block_count_0": {"1"},
+				"start_0_1": {"09:00"}, "end_0_1": {"25:00"},
+			},
+			wantBlocks: []string{"session 09:00-25:00"},
+			wantErrors: map[string]string{"0_1": "End time must be HH:MM"},
+		},
+		{
+			name: "end before the start",
+			form: url.Values{
+				"day_0": {"2026-03-07"}, "block_count_0": {"1"},
+				"start_0_1": {"10:00"}, "end_0_1": {"09:00"},
+			},
+			wantBlocks: []string{"session 10:00-09:00"},
+			wantErrors: map[string]string{"0_1": "End time must be after the start time"},
+		},
+		{
+			name: "overlapping rows",
+			form: url.Values{
+				"day_0": {"2026-03-07"}, "block_count_0": {"3"},
+				"start_0_1": {"09:00"}, "end_0_1": {"10:30"},
+				"start_0_2": {"11:00"}, "end_0_2": {"11:45"},
+				"start_0_3": {"10:00"}, "end_0_3": {"10:45"},
+			},
+			wantBlocks: []string{"session 09:00-10:30", "session 11:00-11:45", "session 10:00-10:45"},
+			wantErrors: map[string]string{"0_3": "Overlaps Session 1 (09:00–10:30)"},
+		},
+		{
+			name: "a row with a bad time is left out of the overlap check",
+			form: url.Values{
+				"day_0": {"2026-03-07"}, "block_count_0": {"2"},
+				"start_0_1": {"09:00"}, "end_0_1": {"x"},
+				"start_0_2": {"09:30"}, "end_0_2": {"10:15"},
+			},
+			wantBlocks: []string{"session 09:00-x", "session 09:30-10:15"},
+			wantErrors: map[string]string{"0_1": "End time must be HH:MM"},
+		},
+		{
+			name: "spanning row keeps its title; unknown kinds are sessions",
+			form: url.Values{
+				"day_0": {"2026-03-07"}, "block_count_0": {"2"},
+				"start_0_1": {"12:00"}, "end_0_1": {"12:40"}, "kind_0_1": {BlockMeal}, "title_0_1": {" Lunch "},
+				"start_0_2": {"13:00"}, "end_0_2": {"13:45"}, "kind_0_2": {"nap"}, "title_0_2": {"Nap"},
+			},
+			wantBlocks: []string{"meal 12:00-12:40 Lunch", "session 13:00-13:45"},
+			wantErrors: map[string]string{},
+		},
+		{
+			name: "rows are checked per day",
+			form: url.Values{
+				"day_0": {"2026-03-07"}, "block_count_0": {"1"},
+				"start_0_1": {"09:00"}, "end_0_1": {"09:45"},
+				"day_1": {"2026-03-08"}, "block_count_1": {"2"},
+				"start_1_1": {"09:00"}, "end_1_1": {"09:45"},
+				"start_1_2": {"09:15"}, "end_1_2": {"10:00"},
+			},
+			wantBlocks: []string{"session 09:00-09:45", "session 09:00-09:45", "session 09:15-10:00"},
+			wantErrors: map[string]string{"1_2": "Overlaps Session 1 (09:00–09:45)"},
+		},
+		{
+			name: "day that is not a date",
+			form: url.Values{
+				"day_0": {"March 7"}, "block_count_0": {"1"},
+				"start_0_1": {"09:00"}, "end_0_1": {"09:45"},
+			},
+			wantBlocks: []string{"session 09:00-09:45"},
+			wantErrors: map[string]string{"0": "March 7 is not a date (YYYY-MM-DD) – check the event's dates"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/blocks", strings.NewReader(tc.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			p := parseBlockPlan(r)
+
+			var got []string
+			for _, d := range p.Days {
+				for _, b := range d.Blocks {
+					row := b.Kind + " " + b.StartTime + "-" + b.EndTime
+					if b.Title != "" {
+						row += " " + b.Title
+					}
+					got = append(got, row)
+				}
+			}
+			if !reflect.DeepEqual(got, tc.wantBlocks) {
+				t.Errorf("got blocks %q, want %q", got, tc.wantBlocks)
+			}
+			if !reflect.DeepEqual(p.Errors, tc.wantErrors) {
+				t.Errorf("got errors %v, want %v", p.Errors, tc.wantErrors)
+			}
+		})
+	}
+}
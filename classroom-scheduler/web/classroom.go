@@ -3,8 +3,6 @@ package web
 import (
 	"strconv"
 	"net/http"
-	"sort"
-	"time"
 )
 
 // Classroom detail page
@@ -17,8 +15,15 @@ func ClassroomHandler(w http.ResponseWriter, r *http.Request) {
     }
 
     mu.RLock()
-    cl := classroomsCache[id]
-    sess := sessionsCache[id]
+    sc := publicSchedule(r)
+    cl := sc.Classrooms[id]
+    filter := readFilter(r)
+    var sorted []Session
+    for _, s := range roomSchedule(sc, id) {
+        if filter.Match(s) {
+            sorted = append(sorted, s)
+        }
+    }
     mu.RUnlock()
 
     if cl == nil {
@@ -26,16 +31,12 @@ func ClassroomHandler(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    // Sort sessions by start time
-    sorted := append([]Session(nil), sess...)
-    sort.Slice(sorted, func(i, j int) bool {
-        return sorted[i].StartTime < sorted[j].StartTime
-    })
-
     data := struct {
         ID        int
         Name      string
         Sessions  []Session
+        Days      []SessionDay
+        Filter    Filter
 
         Active    string
         PageTitle string
@@ -46,13 +47,44 @@ func ClassroomHandler(w http.ResponseWriter, r *http.Request) {
         ID:        cl.ID,
         Name:      cl.Name,
         Sessions:  sorted,
+        Days:      groupSessionsByDay(sorted),
+        Filter:    filter,
 
         Active:    "",
         PageTitle: cl.Name,
-        Year:      time.Now().Year(),
+        Year:      eventNow().Year(),
         ExtraCSS:  []string{"classroom.css"},
         Flash:     "",
     }
 
     RenderTemplate(w, "classroom.html", data)
 }
+
+// roomSchedule lines a room's sessions up with the blocks they sit in and
+// fills in the all-rooms blocks (Kickoff, Lunch, ...), sorted by day and
+// start time – caller holds mu.
+func roomSchedule(sc Schedule, cid int) []Session {
+	var rows []Session
+	for _, b := range sc.Blocks {
+		if b.Spanning() {
+			b := b
+			rows = append(rows, Session{
+				ClassroomID: cid,
+				Day:         b.Day,
+				StartTime:   b.StartTime,
+				EndTime:     b.EndTime,
+				Title:       b.Label(),
+				Spanning:    &b,
+			})
+			continue
+		}
+		if s := sc.findSession(cid, b.ID); s != nil {
+			rows = append(rows, *s)
+		}
+	}
+	sortSessions(rows)
+	sc.markOverrides(rows)
+	sc.linkSeries(rows)
+	sc.markRepeats(rows)
+	return rows
+}
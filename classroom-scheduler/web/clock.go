@@ -0,0 +1,82 @@
+package web
+
+import (
+	"sync/atomic"
+	"time"
+	_ "time/tzdata" // time zones work even where the host has no zoneinfo
+)
+
+// Clock formats an event can show its times in. Times are always stored as
+// 24-hour "15:04" strings; this only changes what pages print.
+const (
+	Clock24 = "24h"
+	Clock12 = "12h"
+)
+
+// ClockFormat is one choice in the event's time format picker.
+type ClockFormat struct {
+	Value   string
+	Example string
+}
+
+var clockFormats = []ClockFormat{{Clock12, "9:25 AM"}, {Clock24, "09:25"}}
+
+// commonZones are suggested in the time zone field; any IANA name works.
+var commonZones = []string{
+	"America/New_York", "America/Chicago", "America/Denver", "America/Phoenix",
+	"America/Los_Angeles", "America/Anchorage", "Pacific/Honolulu", "UTC",
+}
+
+// eventZone is the current event's time zone. It is read without mu so any
+// code can ask for eventNow.
+var eventZone atomic.Pointer[time.Location]
+
+// loadZone resolves an event's time zone; "" is the server's own.
+func loadZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// useEventZone points eventZone at the current event's zone – caller holds mu.
+func useEventZone() {
+	loc := time.Local
+	if e := findEvent(currentEventID); e != nil {
+		if l, err := loadZone(e.TimeZone); err == nil {
+			loc = l
+		}
+	}
+	eventZone.Store(loc)
+}
+
+// eventNow is the wall clock where the current event takes place.
+func eventNow() time.Time {
+	if loc := eventZone.Load(); loc != nil {
+		return time.Now().In(loc)
+	}
+	return time.Now()
+}
+
+// displayClock formats a stored "15:04" time the way the current event
+// shows times. Anything unreadable is returned as is – caller holds mu.
+func displayClock(hhmm string) string {
+	e := findEvent(currentEventID)
+	if e == nil || e.TimeFormat != Clock12 {
+		return hhmm
+	}
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		return hhmm
+	}
+	return t.Format("3:04 PM")
+}
+
+func validClockFormat(f string) bool {
+	for _, c := range clockFormats {
+		if c.Value == f {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package web
 
 import (
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -14,6 +15,18 @@ func ConfigHandler(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
+	mu.RLock()
+	list := configClassrooms()
+	sessions := sessionsCache
+	base := configFormValues(list, sessions)
+	rev := scheduleRevision
+	mu.RUnlock()
+
+	renderConfig(w, list, sessions, nil, strconv.Itoa(rev), base.Encode(), r.URL.Query().Get("saved"))
+}
+
+// configClassrooms lists every room shown on /config – caller holds mu.
+func configClassrooms() []*Classroom {
 	num := len(classroomsCache)
 	if num == 0 {
 		num = 3
@@ -26,11 +39,55 @@ func ConfigHandler(w http.ResponseWriter, r *http.Request) {
 			list[i-1] = &Classroom{ID: i, Name: "Classroom " + strconv.Itoa(i)}
 		}
 	}
+	return list
+}
+
+// renderConfig draws the config page. errs is keyed like the form fields:
+// "{cid}_{blockID}" for cells and "orphan_{sessionID}" for unscheduled sessions.
+// revision and base are what the form was first drawn from; they stay the
+// same while errors are fixed.
+func renderConfig(w http.ResponseWriter, list []*Classroom, sessions map[int][]Session, errs map[string]string, revision, base, flash string) {
+	mu.RLock()
+	blocks := blocksCache
+	presenters := presentersCache
+	tracks := tracksCache
+	conflicts := analyzeConflicts()
+	covered := coveredCells(sessions)
+	cells := make(map[string]Session)
+	draft := draftSchedule()
+	for cid, row := range sessions {
+		if cid == 0 {
+			continue
+		}
+		row = append([]Session(nil), row...)
+		draft.markOverrides(row)
+		draft.markRepeats(row)
+		for _, s := range row {
+			cells[cellKey(cid, s.BlockID)] = s
+		}
+	}
+	mu.RUnlock()
+
+	// A session in the way of a longer one stays visible so it can be fixed
+	for key := range cells {
+		delete(covered, key)
+	}
 
 	data := struct {
         Classrooms     []*Classroom
-        Sessions       map[int][]Session
+        Cells          map[string]Session
+        Covered        map[string]Session
+        MaxSpan        int
+        Unscheduled    []Session
         GlobalSessions []Block
+        Presenters     []*Presenter
+        Tracks         []*Track
+        Levels         []string
+        Programs       []string
+        Errors         map[string]string
+        Conflicts      []Conflict
+        Revision       string
+        Base           string
 
         Active    string
         PageTitle string
@@ -39,14 +96,25 @@ func ConfigHandler(w http.ResponseWriter, r *http.Request) {
         Flash     string
     }{
         Classrooms:     list,
-        Sessions:       sessionsCache,
-        GlobalSessions: blocksCache,
+        Cells:          cells,
+        Covered:        covered,
+        MaxSpan:        maxSpan,
+        Unscheduled:    sessions[0],
+        GlobalSessions: blocks,
+        Presenters:     presenters,
+        Tracks:         tracks,
+        Levels:         sessionLevels,
+        Programs:       programs,
+        Errors:         errs,
+        Conflicts:      conflicts,
+        Revision:       revision,
+        Base:           base,
 
         Active:    "config",
         PageTitle: "Edit Sessions",
-        Year:      time.Now().Year(),
+        Year:      eventNow().Year(),
         ExtraCSS:  []string{"config.css"},
-        Flash:     r.URL.Query().Get("saved"),
+        Flash:     flash,
     }
 	RenderTemplate(w, "config.html", data)
 }
@@ -58,41 +126,356 @@ func ConfigSaveHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	r.ParseForm()
 	mu.Lock()
-	defer mu.Unlock()
+
+	// Someone saved since this form was drawn – show what they changed
+	// next to ours instead of overwriting it
+	if r.FormValue("revision") != strconv.Itoa(scheduleRevision) {
+		now := configFormValues(configClassrooms(), sessionsCache)
+		m := mergeConfig(r.Form, decodeFormBase(r.FormValue("base")), now)
+		mu.Unlock()
+		renderConfigMerge(w, m)
+		return
+	}
 
 	num := len(classroomsCache)
 	if num == 0 {
 		num = 3
 	}
 
-	// Update names
+	// Names as typed – only applied once everything validates
+	list := make([]*Classroom, num)
 	for i := 1; i <= num; i++ {
-		if _, ok := classroomsCache[i]; !ok {
-			classroomsCache[i] = &Classroom{ID: i, Name: "Classroom " + strconv.Itoa(i)}
+		c := &Classroom{ID: i, Name: "Classroom " + strconv.Itoa(i)}
+		if old, ok := classroomsCache[i]; ok {
+			c.Name = old.Name
+			c.Capacity = old.Capacity
+			c.Building = old.Building
 		}
 		if n := r.FormValue("roomname_" + strconv.Itoa(i)); n != "" {
-			classroomsCache[i].Name = n
+			c.Name = n
+		}
+		if v, ok := r.Form["capacity_"+strconv.Itoa(i)]; ok {
+			c.Capacity, _ = strconv.Atoi(strings.TrimSpace(v[0]))
+			if c.Capacity < 0 {
+				c.Capacity = 0
+			}
 		}
+		if v, ok := r.Form["building_"+strconv.Itoa(i)]; ok {
+			c.Building = strings.TrimSpace(v[0])
+		}
+		list[i-1] = c
 	}
 
-	// Rebuild sessions from global blocks
-	sessionsCache = make(map[int][]Session)
+	// Rebuild each room's sessions from the blocks they sit in, honoring
+	// per-session times and spans. Cells left empty on their block's times
+	// are dropped.
+	errs := make(map[string]string)
+	sessions := make(map[int][]Session)
 	for cid := 1; cid <= num; cid++ {
-		var list []Session
-		for idx, b := range blocksCache {
-			list = append(list, Session{
+		var row []Session
+		covered := make(map[int]string) // block ID → key of the session running on into it
+		for _, b := range blocksCache {
+			if b.Spanning() {
+				continue
+			}
+			key := cellKey(cid, b.ID)
+			span, _ := strconv.Atoi(r.FormValue("span_" + key))
+			s := Session{
 				ClassroomID: cid,
+				BlockID:     b.ID,
+				Span:        min(max(span, 1), maxSpan),
+				Day:         b.Day,
+				StartTime:   strings.TrimSpace(r.FormValue("start_" + key)),
+				EndTime:     strings.TrimSpace(r.FormValue("end_" + key)),
+				Title:       strings.TrimSpace(r.FormValue("title_" + key)),
+				Presenter:   strings.TrimSpace(r.FormValue("presenter_" + key)),
+				Description: strings.TrimSpace(r.FormValue("desc_" + key)),
+				TrackID:     formTrack(r, "track_"+key),
+				Tags:        parseTags(r.FormValue("tags_" + key)),
+				Programs:    formPrograms(r, "program_"+key),
+			}
+			if validLevel(r.FormValue("level_" + key)) {
+				s.Level = r.FormValue("level_" + key)
+			}
+			// The end shown by default is where the old span finished
+			shownEnd := b.EndTime
+			old := findSession(cid, b.ID)
+			if old != nil {
+				s.ID = old.ID
+				s.Audience = old.Audience
+				s.Length = old.Length
+				s.SeriesID = old.SeriesID
+				s.Part = old.Part
+				if end := spanEnd(b.ID, old.Span); end != "" {
+					shownEnd = end
+				}
+			}
+			// Picked presenters win over typed text
+			for _, v := range r.Form["presenters_"+key] {
+				if id, err := strconv.Atoi(v); err == nil && findPresenter(id) != nil {
+					s.PresenterIDs = append(s.PresenterIDs, id)
+				}
+			}
+			if len(s.PresenterIDs) > 0 {
+				s.Presenter = presenterNames(s.PresenterIDs)
+			}
+			// Repeat rounds only post their times – the rest comes from the original
+			if old != nil && old.RepeatOf != 0 {
+				if r.FormValue("unrepeat_"+key) != "" {
+					continue
+				}
+				s.RepeatOf = old.RepeatOf
+				copyContent(&s, *old)
+			}
+			if s.StartTime == "" {
+				s.StartTime = b.StartTime
+			}
+			run := blockRun(blocksCache, b.ID, s.Span)
+			if s.EndTime == "" || s.EndTime == shownEnd {
+				s.EndTime = run[len(run)-1].EndTime
+			}
+			if s.isEmpty() && !sessionOverridden(s) {
+				continue
+			}
+			if from, ok := covered[b.ID]; ok && errs[from] == "" {
+				errs[from] = "Runs into \"" + sessionLabel(s) + "\" – clear that block first"
+			}
+			for _, nb := range run[1:] {
+				covered[nb.ID] = key
+			}
+			if len(run) < s.Span {
+				errs[key] = "Only " + strconv.Itoa(len(run)) + " block(s) in a row from here before a break or the end of the day"
+			} else if msg := checkSessionTimes(s); msg != "" {
+				errs[key] = msg
+			} else if msg := checkSpanningOverlap(s); msg != "" {
+				errs[key] = msg
+			}
+			row = append(row, s)
+		}
+		sessions[cid] = row
+	}
+
+	// Unscheduled sessions: keep, discard, or place into an empty cell
+	for _, o := range sessionsCache[0] {
+		key := "orphan_" + strconv.Itoa(o.ID)
+		switch target := r.FormValue("place_" + strconv.Itoa(o.ID)); target {
+		case "":
+			sessions[0] = append(sessions[0], o)
+		case "discard":
+			// dropped
+		default:
+			cid, blockID, ok := parseCell(target)
+			b := findBlock(blockID)
+			if !ok || cid < 1 || cid > num || b == nil || b.Spanning() {
+				errs[key] = "Pick a room and session to place it in"
+				sessions[0] = append(sessions[0], o)
+				continue
+			}
+			run := blockRun(blocksCache, blockID, o.Span)
+			if len(run) < o.Span {
+				errs[key] = "Needs " + strconv.Itoa(o.Span) + " blocks in a row from there"
+				sessions[0] = append(sessions[0], o)
+				continue
+			}
+			taken := false
+			for _, rb := range run {
+				taken = taken || cellTaken(sessions[cid], rb.ID)
+			}
+			if taken {
+				errs[key] = list[cid-1].Name + " already has a session then"
+				sessions[0] = append(sessions[0], o)
+				continue
+			}
+			o.ClassroomID = cid
+			o.BlockID = b.ID
+			o.Day = b.Day
+			o.StartTime = b.StartTime
+			o.EndTime = run[len(run)-1].EndTime
+			sessions[cid] = append(sessions[cid], o)
+		}
+	}
+
+	// New rounds of sessions offered more than once
+	for cid := 1; cid <= num; cid++ {
+		for _, s := range sessions[cid] {
+			key := cellKey(cid, s.BlockID)
+			target := r.FormValue("repeat_" + key)
+			if target == "" || s.ID == 0 {
+				continue
+			}
+			tcid, blockID, ok := parseCell(target)
+			b := findBlock(blockID)
+			if !ok || tcid < 1 || tcid > num || b == nil || b.Spanning() {
+				errs[key] = "Pick a room and session for the next round"
+				continue
+			}
+			if !runFree(sessions[tcid], blockID, s.Span) {
+				errs[key] = list[tcid-1].Name + " isn't free then for another round"
+				continue
+			}
+			round := Session{
+				ClassroomID: tcid,
+				BlockID:     b.ID,
+				Span:        s.Span,
+				Day:         b.Day,
 				StartTime:   b.StartTime,
-				EndTime:     b.EndTime,
-				Title:       strings.TrimSpace(r.FormValue("title_" + strconv.Itoa(cid) + "_" + strconv.Itoa(idx))),
-				Presenter:   strings.TrimSpace(r.FormValue("presenter_" + strconv.Itoa(cid) + "_" + strconv.Itoa(idx))),
-				Description: strings.TrimSpace(r.FormValue("desc_" + strconv.Itoa(cid) + "_" + strconv.Itoa(idx))),
-			})
+				EndTime:     spanEnd(b.ID, s.Span),
+				RepeatOf:    repeatRoot(s),
+			}
+			copyContent(&round, s)
+			sessions[tcid] = append(sessions[tcid], round)
 		}
-		sessionsCache[cid] = list
 	}
 
-	saveClassroomsToDB()
-	saveSessionsToDB()
-	http.Redirect(w, r, "/config?saved=All+classrooms+saved+successfully!", http.StatusSeeOther)
-}
\ No newline at end of file
+	for cid := 1; cid <= num; cid++ {
+		sortSessions(sessions[cid])
+		checkRoomOverlaps(cid, sessions[cid], errs)
+	}
+	syncRepeats(sessions)
+	checkSeries(sessions, list, errs)
+
+	if len(errs) > 0 {
+		mu.Unlock()
+		renderConfig(w, list, sessions, errs, r.FormValue("revision"), r.FormValue("base"), "")
+		return
+	}
+
+	rooms := make(map[int]*Classroom, len(list))
+	for _, c := range list {
+		rooms[c.ID] = c
+	}
+	if err := saveSchedule(r.Context(), Schedule{Classrooms: rooms, Sessions: sessions}); err != nil {
+		mu.Unlock()
+		renderConfig(w, list, sessions, nil, r.FormValue("revision"), r.FormValue("base"), saveFailed(err))
+		return
+	}
+	conflicts := analyzeConflicts()
+	mu.Unlock()
+
+	msg := "All classrooms saved successfully!"
+	if len(conflicts) > 0 {
+		msg = "Saved – " + strconv.Itoa(len(conflicts)) + " conflict(s) need a look"
+	}
+	http.Redirect(w, r, "/config?saved="+url.QueryEscape(msg), http.StatusSeeOther)
+}
+
+// parseCell splits a "{cid}_{blockID}" cell key.
+func parseCell(key string) (cid, blockID int, ok bool) {
+	a, b, found := strings.Cut(key, "_")
+	if !found {
+		return 0, 0, false
+	}
+	cid, err1 := strconv.Atoi(a)
+	blockID, err2 := strconv.Atoi(b)
+	return cid, blockID, err1 == nil && err2 == nil
+}
+
+// cellTaken reports whether a session in row takes the block, counting the
+// later blocks of multi-block sessions – caller holds mu.
+func cellTaken(row []Session, blockID int) bool {
+	for _, s := range row {
+		for _, b := range sessionBlocks(s) {
+			if b.ID == blockID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkSessionTimes validates one session's own start/end.
+func checkSessionTimes(s Session) string {
+	start, err := time.Parse("15:04", s.StartTime)
+	if err != nil {
+		return "Start time must be HH:MM"
+	}
+	end, err := time.Parse("15:04", s.EndTime)
+	if err != nil {
+		return "End time must be HH:MM"
+	}
+	if !end.After(start) {
+		return "End time must be after the start time"
+	}
+	return ""
+}
+
+// checkRoomOverlaps flags sessions in one room that run into their
+// neighbors on the same day. list must be sorted with sortSessions.
+func checkRoomOverlaps(cid int, list []Session, errs map[string]string) {
+	for i := 1; i < len(list); i++ {
+		cur := list[i]
+		// prev is whichever earlier session on the day ends last
+		var prev *Session
+		for j := range list[:i] {
+			if list[j].Day == cur.Day && (prev == nil || list[j].EndTime > prev.EndTime) {
+				prev = &list[j]
+			}
+		}
+		if prev != nil && cur.StartTime < prev.EndTime {
+			key := strconv.Itoa(cid) + "_" + strconv.Itoa(cur.BlockID)
+			if errs[key] == "" {
+				errs[key] = "Overlaps \"" + sessionLabel(*prev) + "\" (" + timeRange("", prev.StartTime, prev.EndTime) + ")"
+			}
+		}
+	}
+}
+
+// checkSeries flags series parts that run out of order or leave their room
+// or building, using the room buildings as typed – caller holds mu.
+func checkSeries(sessions map[int][]Session, list []*Classroom, errs map[string]string) {
+	rooms := make(map[int]*Classroom, len(list))
+	for _, c := range list {
+		rooms[c.ID] = c
+	}
+	problems := seriesProblems(sessions, rooms)
+	for cid, row := range sessions {
+		for _, s := range row {
+			key := cellKey(cid, s.BlockID)
+			if msg := problems[s.ID]; msg != "" && errs[key] == "" {
+				errs[key] = msg
+			}
+		}
+	}
+}
+
+// checkSpanningOverlap flags a real session that runs into an all-rooms
+// block (Kickoff, Lunch, ...) – caller holds mu.
+func checkSpanningOverlap(s Session) string {
+	if s.isEmpty() && !sessionOverridden(s) {
+		return ""
+	}
+	for _, b := range blocksCache {
+		if b.Spanning() && b.Day == s.Day && s.StartTime < b.EndTime && b.StartTime < s.EndTime {
+			return "Runs into " + b.Label() + " (" + timeRange("", b.StartTime, b.EndTime) + ")"
+		}
+	}
+	return ""
+}
+
+// sessionOverridden reports whether a session's times differ from its
+// blocks' – caller holds mu.
+func sessionOverridden(s Session) bool {
+	return draftSchedule().sessionOverridden(s)
+}
+
+func (sc Schedule) sessionOverridden(s Session) bool {
+	run := sc.sessionBlocks(s)
+	if len(run) == 0 {
+		return false
+	}
+	return run[0].StartTime != s.StartTime || run[len(run)-1].EndTime != s.EndTime
+}
+
+// markOverrides sets Override on a copy of sessions for display – caller holds mu.
+func (sc Schedule) markOverrides(sessions []Session) {
+	for i := range sessions {
+		sessions[i].Override = sc.sessionOverridden(sessions[i])
+	}
+}
+
+func sessionLabel(s Session) string {
+	if s.Title != "" {
+		return s.Title
+	}
+	return "untitled session"
+}
@@ -0,0 +1,408 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MergeField is one form field someone changed on a /config form that was
+// out of date by the time they saved it.
+type MergeField struct {
+	Name  string   // form field, e.g. "title_1_3"
+	Label string   // "Title"
+	Was   string   // when their form was loaded
+	Yours string   // what they typed
+	Now   string   // what has been saved since
+	Mine  []string // Yours as posted – sent again when applied
+}
+
+// MergeCell groups the fields of one session cell, room or unscheduled
+// session, which are applied together.
+type MergeCell struct {
+	Key      string // "1_3", "room_1" or "orphan_7"
+	Label    string
+	Fields   []MergeField
+	Conflict bool // someone else changed one of these fields too
+}
+
+// configMerge is what the merge view needs: the cells, plus the revision
+// and form values they were compared with.
+type configMerge struct {
+	Cells       []MergeCell
+	Revision    string
+	Base        string
+	UnknownBase bool // the form carried no base – "was" can't be shown
+}
+
+// mergeFields name the editable /config fields by form prefix, in the order
+// the form shows them.
+var mergeFields = []struct{ prefix, label string }{
+	{"roomname", "Room name"},
+	{"capacity", "Seats"},
+	{"building", "Building"},
+	{"place", "Placement"},
+	{"start", "Start"},
+	{"end", "End"},
+	{"span", "Length"},
+	{"unrepeat", "Drop this round"},
+	{"title", "Title"},
+	{"presenters", "Presenters"},
+	{"presenter", "Presenter"},
+	{"desc", "Description"},
+	{"track", "Track"},
+	{"tags", "Tags"},
+	{"level", "Level"},
+	{"program", "Programs"},
+	{"repeat", "Offer again in"},
+}
+
+func mergeFieldRank(prefix string) int {
+	for i, f := range mergeFields {
+		if f.prefix == prefix {
+			return i
+		}
+	}
+	return -1
+}
+
+// Apply the picked cells of a merge on top of what is saved now
+func ConfigMergeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	r.ParseForm()
+	apply := make(map[string]bool)
+	for _, key := range r.Form["apply"] {
+		apply[key] = true
+	}
+
+	base := decodeFormBase(r.FormValue("base"))
+	mine := url.Values{}
+	for k, v := range base {
+		mine[k] = v
+	}
+	for _, f := range r.Form["touched"] {
+		mine[f] = r.Form["mine:"+f]
+	}
+
+	mu.RLock()
+	now := configFormValues(configClassrooms(), sessionsCache)
+	rev := strconv.Itoa(scheduleRevision)
+	if r.FormValue("revision") != rev {
+		// Saved again meanwhile – compare once more
+		m := mergeConfig(mine, base, now)
+		mu.RUnlock()
+		renderConfigMerge(w, m)
+		return
+	}
+	mu.RUnlock()
+
+	merged := url.Values{}
+	for k, v := range now {
+		merged[k] = v
+	}
+	applied := 0
+	for _, f := range r.Form["touched"] {
+		if key, _ := mergeCellKey(f); !apply[key] {
+			continue
+		}
+		if v := r.Form["mine:"+f]; len(v) > 0 {
+			merged[f] = v
+		} else {
+			delete(merged, f)
+		}
+		applied++
+	}
+	if applied == 0 {
+		http.Redirect(w, r, "/config?saved=Nothing+applied+–+this+is+the+current+version", http.StatusSeeOther)
+		return
+	}
+	merged.Set("revision", rev)
+	merged.Set("base", now.Encode())
+	r.Form, r.PostForm = merged, merged
+	ConfigSaveHandler(w, r)
+}
+
+// configFormValues is the /config form as it is drawn for list and
+// sessions, field by field – caller holds mu. It mirrors config.html.
+func configFormValues(list []*Classroom, sessions map[int][]Session) url.Values {
+	v := url.Values{}
+	for _, c := range list {
+		id := strconv.Itoa(c.ID)
+		v.Set("roomname_"+id, c.Name)
+		v.Set("capacity_"+id, "")
+		if c.Capacity != 0 {
+			v.Set("capacity_"+id, strconv.Itoa(c.Capacity))
+		}
+		v.Set("building_"+id, c.Building)
+	}
+	for _, o := range sessions[0] {
+		v.Set("place_"+strconv.Itoa(o.ID), "")
+	}
+
+	covered := coveredCells(sessions)
+	cells := make(map[string]Session)
+	for cid, row := range sessions {
+		if cid == 0 {
+			continue
+		}
+		for _, s := range row {
+			cells[cellKey(cid, s.BlockID)] = s
+		}
+	}
+	for _, c := range list {
+		for _, b := range blocksCache {
+			if b.Spanning() {
+				continue
+			}
+			key := cellKey(c.ID, b.ID)
+			s, ok := cells[key]
+			if _, cont := covered[key]; cont && !ok {
+				continue
+			}
+			start, end := b.StartTime, b.EndTime
+			if s.StartTime != "" {
+				start, end = s.StartTime, s.EndTime
+			}
+			v.Set("start_"+key, start)
+			v.Set("end_"+key, end)
+			v.Set("span_"+key, strconv.Itoa(max(s.Span, 1)))
+			if s.RepeatOf != 0 {
+				v.Set("unrepeat_"+key, "")
+				continue
+			}
+			v.Set("title_"+key, s.Title)
+			for _, id := range s.PresenterIDs {
+				v.Add("presenters_"+key, strconv.Itoa(id))
+			}
+			v.Set("presenter_"+key, "")
+			if len(s.PresenterIDs) == 0 {
+				v.Set("presenter_"+key, s.Presenter)
+			}
+			v.Set("desc_"+key, s.Description)
+			v.Set("track_"+key, strconv.Itoa(s.TrackID))
+			v.Set("tags_"+key, strings.Join(s.Tags, ", "))
+			v.Set("level_"+key, s.Level)
+			for _, p := range s.Programs {
+				v.Add("program_"+key, p)
+			}
+			if s.ID != 0 {
+				v.Set("repeat_"+key, "")
+			}
+		}
+	}
+	return v
+}
+
+// decodeFormBase reads the base a form carries; nil when there is none.
+func decodeFormBase(s string) url.Values {
+	if s == "" {
+		return nil
+	}
+	v, err := url.ParseQuery(s)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// mergeConfig lines up what someone changed on an out-of-date form (mine
+// against base) with what is saved now – caller holds mu. Without a base
+// every field that differs from now counts as theirs, none preselected.
+func mergeConfig(mine, base, now url.Values) configMerge {
+	m := configMerge{Revision: strconv.Itoa(scheduleRevision), Base: now.Encode(), UnknownBase: base == nil}
+	index := make(map[string]int)
+	for _, f := range sortedFields(mine, base, now) {
+		if f == "revision" || f == "base" {
+			continue
+		}
+		key, prefix := mergeCellKey(f)
+		rank := mergeFieldRank(prefix)
+		if rank < 0 {
+			continue
+		}
+		yours, now1 := formText(f, mine[f]), formText(f, now[f])
+		if yours == now1 {
+			continue // nothing to apply
+		}
+		var was string
+		if base != nil {
+			was = formText(f, base[f])
+			if yours == was {
+				continue // not theirs
+			}
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(m.Cells)
+			index[key] = i
+			m.Cells = append(m.Cells, MergeCell{Key: key, Label: mergeCellLabel(key)})
+		}
+		c := &m.Cells[i]
+		c.Fields = append(c.Fields, MergeField{
+			Name:  f,
+			Label: mergeFields[rank].label,
+			Was:   displayFormValue(prefix, base[f]),
+			Yours: displayFormValue(prefix, mine[f]),
+			Now:   displayFormValue(prefix, now[f]),
+			Mine:  mine[f],
+		})
+		if base == nil || was != now1 {
+			c.Conflict = true
+		}
+	}
+	sort.SliceStable(m.Cells, func(i, j int) bool { return m.Cells[i].Label < m.Cells[j].Label })
+	for _, c := range m.Cells {
+		sort.SliceStable(c.Fields, func(i, j int) bool {
+			_, a := mergeCellKey(c.Fields[i].Name)
+			_, b := mergeCellKey(c.Fields[j].Name)
+			return mergeFieldRank(a) < mergeFieldRank(b)
+		})
+	}
+	return m
+}
+
+func sortedFields(sets ...url.Values) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, s := range sets {
+		for k := range s {
+			if !seen[k] {
+				seen[k] = true
+				names = append(names, k)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// mergeCellKey splits a form field into the cell it belongs to and its
+// prefix: "title_1_3" → ("1_3", "title"), "roomname_2" → ("room_2",
+// "roomname"), "place_7" → ("orphan_7", "place").
+func mergeCellKey(field string) (key, prefix string) {
+	prefix, rest, _ := strings.Cut(field, "_")
+	switch prefix {
+	case "roomname", "capacity", "building":
+		return "room_" + rest, prefix
+	case "place":
+		return "orphan_" + rest, prefix
+	}
+	return rest, prefix
+}
+
+// mergeCellLabel – caller holds mu.
+func mergeCellLabel(key string) string {
+	if id, ok := strings.CutPrefix(key, "room_"); ok {
+		n, _ := strconv.Atoi(id)
+		return roomName(n) + " – room details"
+	}
+	if id, ok := strings.CutPrefix(key, "orphan_"); ok {
+		n, _ := strconv.Atoi(id)
+		for _, s := range sessionsCache[0] {
+			if s.ID == n {
+				return "Unscheduled – " + sessionLabel(s)
+			}
+		}
+		return "Unscheduled session"
+	}
+	return cellLabel(key)
+}
+
+// cellLabel names a "{cid}_{blockID}" cell, e.g. "Room 101, 9:00 AM – 9:50 AM"
+// – caller holds mu.
+func cellLabel(key string) string {
+	cid, blockID, ok := parseCell(key)
+	if !ok {
+		return key
+	}
+	if b := findBlock(blockID); b != nil {
+		return roomName(cid) + ", " + timeRange(b.Day, b.StartTime, b.EndTime)
+	}
+	return roomName(cid) + ", a removed block"
+}
+
+// formText is a field's values for comparing: typed whitespace, line
+// endings and tag spacing don't count as a change.
+func formText(field string, vals []string) string {
+	out := make([]string, len(vals))
+	for i, v := range vals {
+		out[i] = strings.TrimSpace(strings.ReplaceAll(v, "\r\n", "\n"))
+	}
+	if strings.HasPrefix(field, "tags_") {
+		return strings.Join(parseTags(strings.Join(out, ",")), ",")
+	}
+	return strings.Join(out, "\x00")
+}
+
+// displayFormValue shows a field's values for people – caller holds mu.
+func displayFormValue(prefix string, vals []string) string {
+	var parts []string
+	for _, v := range vals {
+		if v = strings.TrimSpace(v); v != "" {
+			parts = append(parts, v)
+		}
+	}
+	if len(parts) == 0 {
+		return "–"
+	}
+	v := parts[0]
+	switch prefix {
+	case "start", "end":
+		return displayClock(v)
+	case "span":
+		if v == "1" {
+			return "One block"
+		}
+		return v + " blocks in a row"
+	case "track":
+		id, _ := strconv.Atoi(v)
+		if t := findTrack(id); t != nil {
+			return t.Name
+		}
+		return "No track"
+	case "level":
+		return levelLabel(v)
+	case "presenters":
+		var ids []int
+		for _, p := range parts {
+			if id, err := strconv.Atoi(p); err == nil {
+				ids = append(ids, id)
+			}
+		}
+		return presenterNames(ids)
+	case "place", "repeat":
+		if v == "discard" {
+			return "Discard"
+		}
+		return cellLabel(v)
+	case "unrepeat":
+		return "Yes"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func renderConfigMerge(w http.ResponseWriter, m configMerge) {
+	data := struct {
+		configMerge
+
+		Active    string
+		PageTitle string
+		Year      int
+		ExtraCSS  []string
+		Flash     string
+	}{
+		configMerge: m,
+
+		Active:    "config",
+		PageTitle: "Merge Changes",
+		Year:      eventNow().Year(),
+		ExtraCSS:  []string{"config.css"},
+	}
+	RenderTemplate(w, "config_merge.html", data)
+}
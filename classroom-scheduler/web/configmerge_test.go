@@ -0,0 +1,174 @@
+package web
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// useTestSchedule fills the caches with one room, two blocks and a session
+// in the first, until the test ends.
+func useTestSchedule(t *testing.T) {
+	t.Helper()
+	rooms, blocks, sessions, tracks := classroomsCache, blocksCache, sessionsCache, tracksCache
+	t.Cleanup(func() { classroomsCache, blocksCache, sessionsCache, tracksCache = rooms, blocks, sessions, tracks })
+
+	classroomsCache = map[int]*Classroom{1: {ID: 1, Name: "Cascade"}}
+	blocksCache = []Block{
+		{ID: 1, StartTime: "09:00", EndTime: "09:45", Kind: BlockSession},
+		{ID: 2, StartTime: "10:00", EndTime: "10:45", Kind: BlockSession},
+	}
+	sessionsCache = map[int][]Session{1: {testSession()}}
+	tracksCache = []*Track{{ID: 1, Name: "Robotics"}, {ID: 2, Name: "Outreach"}}
+}
+
+func testSession() Session {
+	return Session{ID: 7, ClassroomID: 1, BlockID: 1, Span: 1, StartTime: "09:00", EndTime: "09:45",
+		Title: "Knots", Presenter: "Ada", Description: "Bring rope", TrackID: 1, Tags: []string{"ropes", "knots"}}
+}
+
+func TestMergeConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		saved  func(s *Session, c *Classroom) // what was saved since the form was loaded
+		yours  func(v url.Values)             // what was typed on the form
+		noBase bool
+		want   []string // "cell: fields", with " conflict" when both sides changed one
+	}{
+		{
+			name:  "nothing saved since",
+			saved: func(s *Session, c *Classroom) {},
+			yours: func(v url.Values) { v.Set("title_1_1", "Splices") },
+			want:  []string{"1_1: title_1_1"},
+		},
+		{
+			name:  "other fields of the cell saved since",
+			saved: func(s *Session, c *Classroom) { s.Description = "Rope provided" },
+			yours: func(v url.Values) { v.Set("title_1_1", "Splices") },
+			want:  []string{"1_1: title_1_1"},
+		},
+		{
+			name:  "same field saved since",
+			saved: func(s *Session, c *Classroom) { s.Title = "Hitches" },
+			yours: func(v url.Values) { v.Set("title_1_1", "Splices") },
+			want:  []string{"1_1: title_1_1 conflict"},
+		},
+		{
+			name:  "same change saved since",
+			saved: func(s *Session, c *Classroom) { s.Title = "Splices" },
+			yours: func(v url.Values) { v.Set("title_1_1", "Splices") },
+			want:  nil,
+		},
+		{
+			name:  "only someone else's change",
+			saved: func(s *Session, c *Classroom) { s.Title = "Hitches"; s.TrackID = 2 },
+			yours: func(v url.Values) {},
+			want:  nil,
+		},
+		{
+			name:  "whitespace and tag spacing are not changes",
+			saved: func(s *Session, c *Classroom) { s.Title = "Hitches" },
+			yours: func(v url.Values) {
+				v.Set("desc_1_1", "  Bring rope\r\n")
+				v.Set("tags_1_1", "ropes ,  knots")
+			},
+			want: nil,
+		},
+		{
+			name:  "fields of one cell keep the form's order",
+			saved: func(s *Session, c *Classroom) { s.Level = LevelRookie },
+			yours: func(v url.Values) {
+				v.Set("track_1_1", "2")
+				v.Set("title_1_1", "Splices")
+				v.Set("level_1_1", LevelVeteran)
+				v.Set("start_1_1", "09:05")
+			},
+			want: []string{"1_1: start_1_1 title_1_1 track_1_1 level_1_1 conflict"},
+		},
+		{
+			name:  "room and session cells apart, by label",
+			saved: func(s *Session, c *Classroom) { c.Name = "Cascade Hall" },
+			yours: func(v url.Values) {
+				v.Set("roomname_1", "Cascade A")
+				v.Set("building_1", "Main")
+				v.Set("title_1_2", "Relay")
+			},
+			want: []string{"room_1: roomname_1 building_1 conflict", "1_2: title_1_2"},
+		},
+		{
+			name:   "form without a base",
+			saved:  func(s *Session, c *Classroom) { s.Description = "Rope provided" },
+			yours:  func(v url.Values) { v.Set("title_1_1", "Splices") },
+			noBase: true,
+			want:   []string{"1_1: title_1_1 desc_1_1 conflict"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			useTestSchedule(t)
+			base := configFormValues(configClassrooms(), sessionsCache)
+			mine := url.Values{}
+			for k, v := range base {
+				mine[k] = append([]string(nil), v...)
+			}
+			tc.yours(mine)
+
+			s, c := testSession(), *classroomsCache[1]
+			tc.saved(&s, &c)
+			classroomsCache = map[int]*Classroom{1: &c}
+			sessionsCache = map[int][]Session{1: {s}}
+			now := configFormValues(configClassrooms(), sessionsCache)
+
+			if tc.noBase {
+				base = nil
+			}
+			m := mergeConfig(mine, base, now)
+			var got []string
+			for _, c := range m.Cells {
+				cell := c.Key + ":"
+				for _, f := range c.Fields {
+					cell += " " + f.Name
+				}
+				if c.Conflict {
+					cell += " conflict"
+				}
+				got = append(got, cell)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+			if m.UnknownBase != tc.noBase {
+				t.Errorf("UnknownBase %v, want %v", m.UnknownBase, tc.noBase)
+			}
+			if m.Base != now.Encode() {
+				t.Error("merge is not based on what is saved now")
+			}
+		})
+	}
+}
+
+func TestMergeFieldShowsEachSide(t *testing.T) {
+	useTestSchedule(t)
+	base := configFormValues(configClassrooms(), sessionsCache)
+	mine := url.Values{}
+	for k, v := range base {
+		mine[k] = v
+	}
+	mine.Set("track_1_1", "2")
+
+	s := testSession()
+	s.TrackID = 0
+	sessionsCache = map[int][]Session{1: {s}}
+	now := configFormValues(configClassrooms(), sessionsCache)
+
+	m := mergeConfig(mine, base, now)
+	if len(m.Cells) != 1 || len(m.Cells[0].Fields) != 1 {
+		t.Fatalf("got %+v, want one track field", m.Cells)
+	}
+	want := MergeField{Name: "track_1_1", Label: "Track", Was: "Robotics", Yours: "Outreach", Now: "No track", Mine: []string{"2"}}
+	if got := m.Cells[0].Fields[0]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got, want := m.Cells[0].Label, "Cascade, 09:00–09:45"; got != want {
+		t.Errorf("cell label %q, want %q", got, want)
+	}
+}
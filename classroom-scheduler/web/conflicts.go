@@ -0,0 +1,231 @@
+package web
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Conflict kinds
+const (
+	ConflictPresenter = "presenter"
+	ConflictTeam      = "team"
+	ConflictRoom      = "room"
+	ConflictOutside   = "outside"
+	ConflictSeries    = "series"
+)
+
+// Conflict is one problem found by analyzeConflicts.
+type Conflict struct {
+	Kind     string
+	Message  string
+	When     string
+	Sessions []string // "Pneumatics (Cascade)"
+}
+
+// Conflicts report page
+func ConflictsHandler(w http.ResponseWriter, r *http.Request) {
+	mu.RLock()
+	conflicts := analyzeConflicts()
+	mu.RUnlock()
+
+	data := struct {
+		Conflicts []Conflict
+
+		Active    string
+		PageTitle string
+		Year      int
+		ExtraCSS  []string
+		Flash     string
+	}{
+		Conflicts: conflicts,
+
+		Active:    "conflicts",
+		PageTitle: "Conflicts",
+		Year:      eventNow().Year(),
+		ExtraCSS:  []string{"conflicts.css"},
+		Flash:     "",
+	}
+	RenderTemplate(w, "conflicts.html", data)
+}
+
+// analyzeConflicts checks every scheduled session for presenter and team
+// double-booking, rooms with two sessions at once, sessions outside the
+// day's blocks and series parts out of order or place – caller holds mu.
+func analyzeConflicts() []Conflict {
+	var scheduled []Session
+	for cid, list := range sessionsCache {
+		if cid == 0 {
+			continue
+		}
+		for _, s := range list {
+			if !s.isEmpty() {
+				scheduled = append(scheduled, s)
+			}
+		}
+	}
+	sortSessions(scheduled)
+	series := seriesProblems(sessionsCache, classroomsCache)
+
+	var out []Conflict
+	for i := 0; i < len(scheduled); i++ {
+		a := scheduled[i]
+		for j := i + 1; j < len(scheduled); j++ {
+			b := scheduled[j]
+			// sorted by start, so b never starts before a
+			if a.Day != b.Day || b.StartTime >= a.EndTime {
+				continue
+			}
+			when := overlapRange(a, b)
+			pair := []string{conflictItem(a), conflictItem(b)}
+
+			if a.ClassroomID == b.ClassroomID {
+				out = append(out, Conflict{ConflictRoom, roomName(a.ClassroomID) + " has two sessions at once", when, pair})
+			}
+			if shared := sharedStrings(sessionPeople(a), sessionPeople(b)); len(shared) > 0 {
+				out = append(out, Conflict{ConflictPresenter, strings.Join(shared, ", ") + " double-booked", when, pair})
+			} else if shared := sharedStrings(sessionTeams(a), sessionTeams(b)); len(shared) > 0 {
+				out = append(out, Conflict{ConflictTeam, "Team " + strings.Join(shared, ", ") + " presenting in two rooms", when, pair})
+			}
+		}
+		if msg := outsideBlocks(a); msg != "" {
+			out = append(out, Conflict{ConflictOutside, msg, timeRange(a.Day, a.StartTime, a.EndTime), []string{conflictItem(a)}})
+		}
+		if msg := series[a.ID]; msg != "" {
+			out = append(out, Conflict{ConflictSeries, msg, timeRange(a.Day, a.StartTime, a.EndTime), []string{conflictItem(a)}})
+		}
+	}
+	return out
+}
+
+// sessionPeople is who presents a session: linked presenters by name, or the
+// names in the free-text presenter field.
+func sessionPeople(s Session) []string {
+	if len(s.PresenterIDs) > 0 {
+		var names []string
+		for _, id := range s.PresenterIDs {
+			if p := findPresenter(id); p != nil {
+				names = append(names, p.Name)
+			}
+		}
+		return names
+	}
+	return splitPresenterNames(s.Presenter)
+}
+
+// sessionTeams is the set of team numbers presenting a session.
+func sessionTeams(s Session) []string {
+	var teams []string
+	if len(s.PresenterIDs) > 0 {
+		for _, id := range s.PresenterIDs {
+			if p := findPresenter(id); p != nil && p.TeamNumber != "" {
+				teams = append(teams, p.TeamNumber)
+			}
+		}
+		return teams
+	}
+	for _, name := range splitPresenterNames(s.Presenter) {
+		if team, ok := strings.CutPrefix(name, "Team "); ok {
+			teams = append(teams, team)
+		}
+	}
+	return teams
+}
+
+// outsideBlocks reports a session that isn't covered from start to end by
+// its day's session blocks, one running on from the other – so one sitting
+// in a passing period or over lunch counts – or that runs into an all-rooms
+// block – caller holds mu.
+func outsideBlocks(s Session) string {
+	var blocks []Block
+	for _, b := range blocksCache {
+		if b.Day != s.Day {
+			continue
+		}
+		if b.Spanning() {
+			if s.StartTime < b.EndTime && b.StartTime < s.EndTime {
+				return sessionLabel(s) + " runs into " + b.Label()
+			}
+			continue
+		}
+		blocks = append(blocks, b)
+	}
+	if len(blocks) == 0 {
+		return sessionLabel(s) + " is on a day with no session blocks"
+	}
+
+	// Walk from the start through the blocks covering each moment
+	first, last := blocks[0].StartTime, blocks[0].EndTime
+	for _, b := range blocks {
+		first, last = min(first, b.StartTime), max(last, b.EndTime)
+	}
+	for at := s.StartTime; at < s.EndTime; {
+		reach, next := at, ""
+		for _, b := range blocks {
+			if b.StartTime <= at && b.EndTime > reach {
+				reach = b.EndTime
+			}
+			if b.StartTime > at && (next == "" || b.StartTime < next) {
+				next = b.StartTime
+			}
+		}
+		switch {
+		case reach > at:
+			at = reach
+			continue
+		case at < first:
+			return sessionLabel(s) + " starts before the first block (" + displayClock(first) + ")"
+		case next == "":
+			return sessionLabel(s) + " ends after the last block (" + displayClock(last) + ")"
+		}
+		return sessionLabel(s) + " runs into the gap between blocks from " + displayClock(at) + " to " + displayClock(next)
+	}
+	return ""
+}
+
+func sharedStrings(a, b []string) []string {
+	seen := make(map[string]bool)
+	for _, v := range a {
+		seen[strings.ToLower(v)] = true
+	}
+	var out []string
+	for _, v := range b {
+		if seen[strings.ToLower(v)] {
+			out = append(out, v)
+			delete(seen, strings.ToLower(v))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func overlapRange(a, b Session) string {
+	start, end := a.StartTime, a.EndTime
+	if b.StartTime > start {
+		start = b.StartTime
+	}
+	if b.EndTime < end {
+		end = b.EndTime
+	}
+	return timeRange(a.Day, start, end)
+}
+
+func conflictItem(s Session) string {
+	return sessionLabel(s) + " (" + roomName(s.ClassroomID) + ")"
+}
+
+// roomName – caller holds mu.
+func roomName(cid int) string {
+	return draftSchedule().roomName(cid)
+}
+
+func (sc Schedule) roomName(cid int) string {
+	if c, ok := sc.Classrooms[cid]; ok {
+		return c.Name
+	}
+	if cid == 0 {
+		return "Unscheduled"
+	}
+	return "Classroom " + strconv.Itoa(cid)
+}
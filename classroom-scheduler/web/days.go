@@ -0,0 +1,102 @@
+package web
+
+import (
+	"sort"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// maxEventDays keeps a typo in the end date from producing a year of blocks.
+const maxEventDays = 14
+
+// eventDays lists every date from the event's start to end date.
+// Events without dates are a single, undated day ("").
+func eventDays(e *Event) []string {
+	if e == nil || e.StartDate == "" {
+		return []string{""}
+	}
+	start, err := time.Parse(dateLayout, e.StartDate)
+	if err != nil {
+		return []string{""}
+	}
+	end, err := time.Parse(dateLayout, e.EndDate)
+	if err != nil || end.Before(start) {
+		end = start
+	}
+	var days []string
+	for d := start; !d.After(end) && len(days) < maxEventDays; d = d.AddDate(0, 0, 1) {
+		days = append(days, d.Format(dateLayout))
+	}
+	return days
+}
+
+// scheduleDays is every day of the current event plus any day that still
+// has blocks on it (e.g. after the event dates were moved) – caller holds mu.
+func scheduleDays() []string {
+	return draftSchedule().days()
+}
+
+func (sc Schedule) days() []string {
+	seen := make(map[string]bool)
+	var days []string
+	for _, d := range eventDays(findEvent(currentEventID)) {
+		seen[d] = true
+		days = append(days, d)
+	}
+	for _, b := range sc.Blocks {
+		if !seen[b.Day] {
+			seen[b.Day] = true
+			days = append(days, b.Day)
+		}
+	}
+	sort.Strings(days)
+	return days
+}
+
+// sortBlocks orders blocks by day, then start time.
+func sortBlocks(blocks []Block) {
+	sort.SliceStable(blocks, func(i, j int) bool {
+		if blocks[i].Day != blocks[j].Day {
+			return blocks[i].Day < blocks[j].Day
+		}
+		return blocks[i].StartTime < blocks[j].StartTime
+	})
+}
+
+// sortSessions orders sessions by day, then start time.
+func sortSessions(sessions []Session) {
+	sort.SliceStable(sessions, func(i, j int) bool {
+		if sessions[i].Day != sessions[j].Day {
+			return sessions[i].Day < sessions[j].Day
+		}
+		return sessions[i].StartTime < sessions[j].StartTime
+	})
+}
+
+// SessionDay is one day's worth of sessions for a page that groups by day.
+type SessionDay struct {
+	Day      string
+	Sessions []Session
+}
+
+// groupSessionsByDay expects sessions already sorted with sortSessions.
+func groupSessionsByDay(sessions []Session) []SessionDay {
+	var days []SessionDay
+	for _, s := range sessions {
+		if len(days) == 0 || days[len(days)-1].Day != s.Day {
+			days = append(days, SessionDay{Day: s.Day})
+		}
+		days[len(days)-1].Sessions = append(days[len(days)-1].Sessions, s)
+	}
+	return days
+}
+
+// dayLabel renders "2024-11-02" as "Saturday, Nov 2".
+func dayLabel(day string) string {
+	t, err := time.Parse(dateLayout, day)
+	if err != nil {
+		return day
+	}
+	return t.Format("Monday, Jan 2")
+}
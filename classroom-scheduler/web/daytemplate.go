@@ -0,0 +1,230 @@
+package web
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DayTemplate is the handful of inputs a day of blocks is generated from.
+// Session length and passing time are the /blocks settings of the same name.
+type DayTemplate struct {
+	Start         string // "08:00"
+	End           string // last session ends by this time
+	SessionLength int
+	Passing       int    // default minutes between blocks
+	LunchFrom     string // lunch starts no earlier than this...
+	LunchTo       string // ...and is over by this
+	LunchMinutes  int    // 0 = no lunch block
+}
+
+// dayTemplate holds the current event's day shape; SessionLength and
+// Passing live in sessionLengthMinutes and breakMinutes.
+var dayTemplate = defaultDayTemplate
+
+var defaultDayTemplate = DayTemplate{Start: "08:00", End: "16:40", LunchFrom: "11:30", LunchTo: "13:30", LunchMinutes: 40}
+
+// currentDayTemplate – caller holds mu.
+func currentDayTemplate() DayTemplate {
+	t := dayTemplate
+	t.SessionLength = sessionLengthMinutes
+	t.Passing = breakMinutes
+	return t
+}
+
+// loadDayTemplate reads the current event's day shape from its settings,
+// keeping the defaults for anything missing or unreadable.
+func loadDayTemplate() {
+	dayTemplate = defaultDayTemplate
+	for key, dst := range map[string]*string{
+		"day_start":  &dayTemplate.Start,
+		"day_end":    &dayTemplate.End,
+		"lunch_from": &dayTemplate.LunchFrom,
+		"lunch_to":   &dayTemplate.LunchTo,
+	} {
+		if val, err := eventSetting(key); err == nil && validClock(val) {
+			*dst = val
+		} else if err != nil && err != sql.ErrNoRows {
+			log.Printf("Failed to load setting %s: %v", key, err)
+		}
+	}
+	if val, err := eventSetting("lunch_minutes"); err == nil {
+		if n, _ := strconv.Atoi(val); n >= 0 && n <= 180 {
+			dayTemplate.LunchMinutes = n
+		}
+	}
+}
+
+// dayTemplateSettings are the event settings t is kept in.
+func dayTemplateSettings(t DayTemplate) map[string]string {
+	return map[string]string{
+		"day_start":     t.Start,
+		"day_end":       t.End,
+		"lunch_from":    t.LunchFrom,
+		"lunch_to":      t.LunchTo,
+		"lunch_minutes": strconv.Itoa(t.LunchMinutes),
+	}
+}
+
+// Generate one day's blocks (or every day's) from the template. The template
+// is kept for the event; the blocks are not saved – they are shown on
+// /blocks to adjust and save as usual.
+func BlocksGenerateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	r.ParseForm()
+	t, errs := parseDayTemplate(r)
+
+	mu.Lock()
+	p := currentBlockPlan()
+	p.Template, p.TemplateErrors = t, errs
+	if len(errs) > 0 {
+		mu.Unlock()
+		renderBlocks(w, p, "")
+		return
+	}
+	if err := saveSchedule(r.Context(), Schedule{Settings: dayTemplateSettings(t)}); err != nil {
+		mu.Unlock()
+		renderBlocks(w, p, saveFailed(err))
+		return
+	}
+	dayTemplate.Start, dayTemplate.End = t.Start, t.End
+	dayTemplate.LunchFrom, dayTemplate.LunchTo, dayTemplate.LunchMinutes = t.LunchFrom, t.LunchTo, t.LunchMinutes
+	mu.Unlock()
+
+	which := r.FormValue("gen_day")
+	generated := 0
+	for i, d := range p.Days {
+		if which != "all" && which != strconv.Itoa(d.Index) {
+			continue
+		}
+		p.Days[i].Blocks = reuseBlockIDs(generateDay(t, d.Day), d.Blocks)
+		generated += len(p.Days[i].Blocks)
+	}
+	p.SessionLength, p.BreakMinutes = t.SessionLength, t.Passing
+
+	renderBlocks(w, p, "Generated "+strconv.Itoa(generated)+" blocks – check the times and gaps, then save")
+}
+
+// parseDayTemplate reads the generator form. Errors are keyed by field name.
+func parseDayTemplate(r *http.Request) (DayTemplate, map[string]string) {
+	errs := make(map[string]string)
+	t := DayTemplate{
+		Start:     strings.TrimSpace(r.FormValue("gen_start")),
+		End:       strings.TrimSpace(r.FormValue("gen_end")),
+		LunchFrom: strings.TrimSpace(r.FormValue("gen_lunch_from")),
+		LunchTo:   strings.TrimSpace(r.FormValue("gen_lunch_to")),
+	}
+	for name, v := range map[string]string{"gen_start": t.Start, "gen_end": t.End, "gen_lunch_from": t.LunchFrom, "gen_lunch_to": t.LunchTo} {
+		if !validClock(v) {
+			errs[name] = "Use HH:MM"
+		}
+	}
+
+	var err error
+	if t.SessionLength, err = strconv.Atoi(r.FormValue("gen_length")); err != nil || t.SessionLength < 20 || t.SessionLength > 300 {
+		errs["gen_length"] = "Between 20 and 300 minutes"
+	}
+	if t.Passing, err = strconv.Atoi(r.FormValue("gen_passing")); err != nil || t.Passing < 0 || t.Passing > 120 {
+		errs["gen_passing"] = "Between 0 and 120 minutes"
+	}
+	if t.LunchMinutes, err = strconv.Atoi(r.FormValue("gen_lunch_minutes")); err != nil || t.LunchMinutes < 0 || t.LunchMinutes > 180 {
+		errs["gen_lunch_minutes"] = "Between 0 and 180 minutes"
+	}
+	if len(errs) > 0 {
+		return t, errs
+	}
+
+	start, end := clockMinutes(t.Start), clockMinutes(t.End)
+	from, to := clockMinutes(t.LunchFrom), clockMinutes(t.LunchTo)
+	switch {
+	case end <= start:
+		errs["gen_end"] = "The day must end after it starts"
+	case end-start < t.SessionLength:
+		errs["gen_end"] = "Not even one session fits"
+	}
+	// generateDay places lunch no later than the window allows, so these
+	// keep it from running past the window or the day
+	if t.LunchMinutes > 0 {
+		switch {
+		case from < start:
+			errs["gen_lunch_from"] = "Lunch can't start before the day does"
+		case to > end:
+			errs["gen_lunch_to"] = "Lunch must be over by the end of the day"
+		case to-from < t.LunchMinutes:
+			errs["gen_lunch_to"] = "The window is shorter than lunch"
+		}
+	}
+	return t, errs
+}
+
+// generateDay lays out session blocks from t.Start, t.Passing apart, and
+// fits lunch in as soon as another session would push it past its window.
+// A lunch that no longer fits the window or the day is left out; a template
+// parseDayTemplate accepts always has room for it.
+func generateDay(t DayTemplate, day string) []Block {
+	var blocks []Block
+	add := func(kind, title string, from, to int) {
+		blocks = append(blocks, Block{Day: day, StartTime: clockTime(from), EndTime: clockTime(to), Kind: kind, Title: title})
+	}
+
+	cursor, end := clockMinutes(t.Start), clockMinutes(t.End)
+	lunchDue := t.LunchMinutes > 0
+	latestLunch := clockMinutes(t.LunchTo) - t.LunchMinutes
+	for {
+		if lunchDue && cursor+t.SessionLength+t.Passing > latestLunch {
+			lunchDue = false
+			at := max(cursor, clockMinutes(t.LunchFrom))
+			if at > latestLunch || at+t.LunchMinutes > end {
+				continue
+			}
+			add(BlockMeal, "Lunch", at, at+t.LunchMinutes)
+			cursor = at + t.LunchMinutes + t.Passing
+			continue
+		}
+		if cursor+t.SessionLength > end {
+			break
+		}
+		add(BlockSession, "", cursor, cursor+t.SessionLength)
+		cursor += t.SessionLength + t.Passing
+	}
+	return blocks
+}
+
+// reuseBlockIDs hands a regenerated day the IDs of its old blocks, kind by
+// kind in order, so placed sessions follow their slot instead of being
+// unscheduled.
+func reuseBlockIDs(blocks, old []Block) []Block {
+	ids := make(map[bool][]int)
+	for _, b := range old {
+		ids[b.Spanning()] = append(ids[b.Spanning()], b.ID)
+	}
+	for i := range blocks {
+		k := blocks[i].Spanning()
+		if len(ids[k]) > 0 {
+			blocks[i].ID = ids[k][0]
+			ids[k] = ids[k][1:]
+		}
+	}
+	return blocks
+}
+
+func validClock(s string) bool {
+	_, err := time.Parse("15:04", s)
+	return err == nil
+}
+
+// clockMinutes turns "HH:MM" into minutes after midnight.
+func clockMinutes(s string) int {
+	t, _ := time.Parse("15:04", s)
+	return t.Hour()*60 + t.Minute()
+}
+
+func clockTime(m int) string {
+	return time.Date(0, 1, 1, 0, m, 0, 0, time.UTC).Format("15:04")
+}
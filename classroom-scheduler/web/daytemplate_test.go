@@ -0,0 +1,93 @@
+package web
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerateDay(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		t    DayTemplate
+		want []string
+	}{
+		{
+			name: "lunch where the next session would push it past its window",
+			t:    DayTemplate{Start: "09:00", End: "15:00", SessionLength: 45, Passing: 15, LunchFrom: "11:30", LunchTo: "13:00", LunchMinutes: 30},
+			want: []string{"session 09:00-09:45", "session 10:00-10:45", "session 11:00-11:45", "meal 12:00-12:30", "session 12:45-13:30", "session 13:45-14:30"},
+		},
+		{
+			name: "lunch window opening with the day",
+			t:    DayTemplate{Start: "09:00", End: "11:00", SessionLength: 45, Passing: 15, LunchFrom: "09:00", LunchTo: "09:30", LunchMinutes: 30},
+			want: []string{"meal 09:00-09:30", "session 09:45-10:30"},
+		},
+		{
+			name: "lunch window closing with the day",
+			t:    DayTemplate{Start: "09:00", End: "12:00", SessionLength: 45, Passing: 15, LunchFrom: "11:00", LunchTo: "12:00", LunchMinutes: 60},
+			want: []string{"session 09:00-09:45", "session 10:00-10:45", "meal 11:00-12:00"},
+		},
+		{
+			name: "no lunch",
+			t:    DayTemplate{Start: "09:00", End: "11:00", SessionLength: 45, Passing: 10, LunchFrom: "11:00", LunchTo: "13:00"},
+			want: []string{"session 09:00-09:45", "session 09:55-10:40"},
+		},
+		{
+			name: "day too short for one session",
+			t:    DayTemplate{Start: "09:00", End: "09:30", SessionLength: 45, Passing: 15, LunchFrom: "11:00", LunchTo: "13:00"},
+			want: nil,
+		},
+		{
+			name: "lunch window past the end of the day is left out",
+			t:    DayTemplate{Start: "09:00", End: "10:00", SessionLength: 45, Passing: 15, LunchFrom: "11:00", LunchTo: "13:00", LunchMinutes: 30},
+			want: []string{"session 09:00-09:45"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, b := range generateDay(tc.t, "2026-03-07") {
+				if b.Day != "2026-03-07" {
+					t.Errorf("block on %q, want 2026-03-07", b.Day)
+				}
+				got = append(got, b.Kind+" "+b.StartTime+"-"+b.EndTime)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseDayTemplateLunch(t *testing.T) {
+	for _, tc := range []struct {
+		name                        string
+		start, end, from, to, lunch string
+		wantField                   string // "" = accepted
+	}{
+		{"window inside the day", "09:00", "15:00", "11:30", "13:00", "30", ""},
+		{"window on the edges of the day", "09:00", "15:00", "09:00", "15:00", "30", ""},
+		{"window starting before the day", "09:00", "15:00", "08:30", "13:00", "30", "gen_lunch_from"},
+		{"window ending after the day", "09:00", "15:00", "14:00", "15:30", "30", "gen_lunch_to"},
+		{"window shorter than lunch", "09:00", "15:00", "12:00", "12:20", "30", "gen_lunch_to"},
+		{"no lunch ignores the window", "09:00", "15:00", "16:00", "17:00", "0", ""},
+		{"day too short for one session", "09:00", "09:30", "09:00", "09:30", "0", "gen_end"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			form := url.Values{
+				"gen_start": {tc.start}, "gen_end": {tc.end}, "gen_length": {"45"}, "gen_passing": {"15"},
+				"gen_lunch_from": {tc.from}, "gen_lunch_to": {tc.to}, "gen_lunch_minutes": {tc.lunch},
+			}
+			r := httptest.NewRequest("POST", "/blocks/generate", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			_, errs := parseDayTemplate(r)
+			if tc.wantField == "" && len(errs) > 0 {
+				t.Errorf("got errors %v, want none", errs)
+			}
+			if tc.wantField != "" && errs[tc.wantField] == "" {
+				t.Errorf("got errors %v, want one for %s", errs, tc.wantField)
+			}
+		})
+	}
+}
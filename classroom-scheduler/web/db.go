@@ -2,10 +2,15 @@
 package web
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"slices"
 	"sync"
 	"strconv"
+	"time"
 	_ "modernc.org/sqlite"
 )
 
@@ -15,122 +20,543 @@ var (
 	classroomsCache        = make(map[int]*Classroom)
 	sessionsCache          = make(map[int][]Session)
 	blocksCache            []Block
+	eventsCache            []*Event
+	presentersCache        []*Presenter
+	tracksCache            []*Track
+	seriesCache            []*Series
+	currentEventID         int // the event in the caches – the one the requests being served are for
+	liveEventID            int // the event the public pages show
+	scheduleRevision       int // the current event's; every saveSchedule counts it up
+	eventsRevision         int // the event list's
 	sessionLengthMinutes   = 45
 	breakMinutes           = 15
 )
 
+// Event is one JUMPSTART (or any other conference) – it owns its own
+// classrooms, blocks and sessions so several years can live in one database.
+type Event struct {
+	ID        int
+	Name      string
+	Location  string
+	StartDate  string // YYYY-MM-DD
+	EndDate    string // YYYY-MM-DD
+	TimeZone   string // IANA name, "" = the server's zone
+	TimeFormat string // Clock12 or Clock24
+}
+
 type Block struct{
 	ID 			int; 
+	Day 		string; // YYYY-MM-DD, "" for single-day events
 	StartTime	string;
-	EndTime 	string 
+	EndTime 	string;
+	Kind 		string; // BlockSession, BlockPlenary, BlockBreak or BlockMeal
+	Title 		string; // non-session blocks only, e.g. "Lunch"
+	Location 	string  // non-session blocks only, e.g. "Garvey Commons"
+}
+
+// Block kinds. Anything other than a session block covers every classroom.
+const (
+	BlockSession = "session"
+	BlockPlenary = "plenary"
+	BlockBreak   = "break"
+	BlockMeal    = "meal"
+)
+
+var blockKinds = []string{BlockSession, BlockPlenary, BlockBreak, BlockMeal}
+
+// Spanning reports whether the block covers every room (Kickoff, Lunch, ...).
+func (b Block) Spanning() bool {
+	return b.Kind != "" && b.Kind != BlockSession
+}
+
+// Label is what attendees see for an all-rooms block.
+func (b Block) Label() string {
+	if b.Title != "" {
+		return b.Title
+	}
+	switch b.Kind {
+	case BlockMeal:
+		return "Lunch"
+	case BlockBreak:
+		return "Break"
+	case BlockPlenary:
+		return "All-Rooms Session"
+	}
+	return ""
 }
 
 type Session struct{
-	ClassroomID int; 
+	ID 			int;
+	ClassroomID int; // 0 = unscheduled (its block was removed)
+	BlockID 	int; // 0 = unscheduled
+	Span 		int; // consecutive blocks taken from BlockID on, 1 = just that block
+	Day 		string;
 	StartTime 	string;
 	EndTime 	string;
 	Title 		string;
-	Presenter 	string;
-	Description string
+	Presenter 	string; // display text – the linked presenters' names, or free text
+	PresenterIDs []int;
+	Description string;
+	Audience 	int;      // expected attendees, 0 = unknown
+	TrackID 	int;      // 0 = no track
+	Tags 		[]string;
+	Level 		string;   // LevelRookie, ... – "" = all levels
+	Programs 	[]string; // FRC, FTC, FLL – none = every program
+	Length 		int;      // minutes needed, 0 = fits any block
+	SeriesID 	int;      // 0 = not part of a series
+	Part 		int;      // position in the series, from 1
+	RepeatOf 	int;      // original session this is another round of, 0 = original
+	Continued 	*SeriesLink // previous part – display only, not stored
+	Continues 	*SeriesLink // next part – display only, not stored
+	AlsoAt 		[]string // other rounds, "13:30 in Cascade" – display only, not stored
+	Override 	bool; // times differ from the block – display only, not stored
+	Spanning 	*Block // all-rooms block filling this slot – display only, not stored
+}
+
+func (s Session) isEmpty() bool {
+	return s.Title == "" && s.Presenter == "" && s.Description == "" && len(s.PresenterIDs) == 0 &&
+		s.TrackID == 0 && len(s.Tags) == 0 && s.Level == "" && len(s.Programs) == 0
+}
+
+// Presenter is a person or team giving sessions, linked many-to-many to sessions.
+type Presenter struct {
+	ID         int
+	Name       string
+	TeamNumber string
+	Email      string
+	Bio        string
+}
+
+// Track groups sessions by topic (Programming, Mechanical, ...) and gives
+// them a color on every schedule page.
+type Track struct {
+	ID    int
+	Name  string
+	Color string // #rrggbb
+}
+
+// Series links sessions that follow on from each other (Part 1, 2, 3 ...).
+// Parts must run in order and stay in one building – or one room if SameRoom.
+type Series struct {
+	ID       int
+	Name     string
+	SameRoom bool
 }
 
 type Classroom struct{
 	ID int; 
-	Name string 
+	Name string;
+	Capacity int; // seats, 0 = unknown
+	Building string // "" = unknown
 }
 
 
-func createTables() {
+// createTables creates every table a new database starts with.
+func createTables(tx dbtx) error {
+	eventsSQL := `
+	CREATE TABLE IF NOT EXISTS events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL DEFAULT '',
+		location TEXT NOT NULL DEFAULT '',
+		start_date TEXT NOT NULL DEFAULT '',  -- YYYY-MM-DD
+		end_date TEXT NOT NULL DEFAULT ''     -- YYYY-MM-DD
+	);`
+
 	classroomsSQL := `
 	CREATE TABLE IF NOT EXISTS classrooms (
-		id INTEGER PRIMARY KEY,
-		name TEXT NOT NULL DEFAULT ''
+		event_id INTEGER NOT NULL,
+		id INTEGER NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		capacity INTEGER NOT NULL DEFAULT 0,
+		building TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY(event_id, id),
+		FOREIGN KEY(event_id) REFERENCES events(id) ON DELETE CASCADE
 	);`
 
 	sessionsSQL := `
 	CREATE TABLE IF NOT EXISTS sessions (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
 		classroom_id INTEGER,
+		block_id INTEGER NOT NULL DEFAULT 0,  -- 0 = unscheduled
+		day TEXT NOT NULL DEFAULT '',  -- YYYY-MM-DD
 		start_time TEXT NOT NULL,  -- HH:MM
 		end_time TEXT NOT NULL,    -- HH:MM
 		title TEXT NOT NULL,
 		presenter TEXT NOT NULL,
 		description TEXT,
-		FOREIGN KEY(classroom_id) REFERENCES classrooms(id) ON DELETE CASCADE
+		audience INTEGER NOT NULL DEFAULT 0,
+		track_id INTEGER NOT NULL DEFAULT 0,  -- 0 = no track
+		tags TEXT NOT NULL DEFAULT '',        -- comma separated
+		level TEXT NOT NULL DEFAULT '',       -- '' = all levels
+		programs TEXT NOT NULL DEFAULT '',    -- comma separated, '' = every program
+		length_minutes INTEGER NOT NULL DEFAULT 0,
+		block_span INTEGER NOT NULL DEFAULT 1,
+		series_id INTEGER NOT NULL DEFAULT 0,  -- 0 = not in a series
+		series_part INTEGER NOT NULL DEFAULT 0,
+		repeat_of INTEGER NOT NULL DEFAULT 0,  -- 0 = original session
+		FOREIGN KEY(event_id) REFERENCES events(id) ON DELETE CASCADE
 	);`
 
 	blocksSQL := `
 	CREATE TABLE IF NOT EXISTS blocks (
-		id INTEGER PRIMARY KEY,
+		event_id INTEGER NOT NULL,
+		id INTEGER NOT NULL,
+		day TEXT NOT NULL DEFAULT '',  -- YYYY-MM-DD
 		start_time TEXT NOT NULL,
-		end_time TEXT NOT NULL
+		end_time TEXT NOT NULL,
+		kind TEXT NOT NULL DEFAULT 'session',
+		title TEXT NOT NULL DEFAULT '',
+		location TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY(event_id, id),
+		FOREIGN KEY(event_id) REFERENCES events(id) ON DELETE CASCADE
+	);`
+
+	presentersSQL := `
+	CREATE TABLE IF NOT EXISTS presenters (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		team_number TEXT NOT NULL DEFAULT '',
+		email TEXT NOT NULL DEFAULT '',
+		bio TEXT NOT NULL DEFAULT '',
+		FOREIGN KEY(event_id) REFERENCES events(id) ON DELETE CASCADE
+	);`
+
+	sessionPresentersSQL := `
+	CREATE TABLE IF NOT EXISTS session_presenters (
+		session_id INTEGER NOT NULL,
+		presenter_id INTEGER NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY(session_id, presenter_id),
+		FOREIGN KEY(session_id) REFERENCES sessions(id) ON DELETE CASCADE,
+		FOREIGN KEY(presenter_id) REFERENCES presenters(id) ON DELETE CASCADE
+	);`
+
+	tracksSQL := `
+	CREATE TABLE IF NOT EXISTS tracks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		color TEXT NOT NULL DEFAULT '#0066cc',
+		position INTEGER NOT NULL DEFAULT 0,
+		FOREIGN KEY(event_id) REFERENCES events(id) ON DELETE CASCADE
+	);`
+
+	seriesSQL := `
+	CREATE TABLE IF NOT EXISTS series (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		same_room INTEGER NOT NULL DEFAULT 0,
+		FOREIGN KEY(event_id) REFERENCES events(id) ON DELETE CASCADE
 	);`
 
-	_, err := DB.Exec(classroomsSQL)
+	_, err := tx.Exec(eventsSQL)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(classroomsSQL)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(sessionsSQL)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(blocksSQL)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(presentersSQL)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(sessionPresentersSQL)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(tracksSQL)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(seriesSQL)
+	if err != nil {
+		return err
+	}
+
+	// Change history – one batch per save, one change per field
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS change_batches (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		at TEXT NOT NULL DEFAULT '',      -- RFC 3339, UTC
+		user TEXT NOT NULL DEFAULT '',
+		action TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE IF NOT EXISTS changes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		batch_id INTEGER NOT NULL,
+		event_id INTEGER NOT NULL,
+		entity TEXT NOT NULL,             -- session, classroom or block
+		entity_id INTEGER NOT NULL,
+		label TEXT NOT NULL DEFAULT '',
+		field TEXT NOT NULL DEFAULT '',   -- '' = created or deleted
+		old_value TEXT NOT NULL DEFAULT '',
+		new_value TEXT NOT NULL DEFAULT '',
+		reverted INTEGER NOT NULL DEFAULT 0,
+		FOREIGN KEY(batch_id) REFERENCES change_batches(id) ON DELETE CASCADE
+	);`)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	_, err = DB.Exec(sessionsSQL)
+
+	// What the public pages show; the newest version not rolled back is live
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS publications (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		at TEXT NOT NULL DEFAULT '',        -- RFC 3339, UTC
+		user TEXT NOT NULL DEFAULT '',      -- '' = published automatically
+		retired INTEGER NOT NULL DEFAULT 0,
+		data TEXT NOT NULL DEFAULT '{}'     -- rooms, blocks and sessions as JSON
+	);`)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	_, err = DB.Exec(blocksSQL)
+
+	// Templates belong to no event – any event can be started from one
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS schedule_templates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL DEFAULT '',
+		created TEXT NOT NULL DEFAULT '',   -- YYYY-MM-DD
+		with_sessions INTEGER NOT NULL DEFAULT 0,
+		data TEXT NOT NULL DEFAULT '{}'     -- Snapshot as JSON
+	);`)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS settings (
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS settings (
     key TEXT PRIMARY KEY,
     value TEXT
 	);`)
+	return err
+}
 
-	if err != nil {
-		log.Fatal(err)
+// laterColumns were added after the first release; databases created before
+// them get them from addLaterColumns.
+var laterColumns = []struct{ table, column, decl string }{
+	{"blocks", "day", "TEXT NOT NULL DEFAULT ''"},
+	{"sessions", "day", "TEXT NOT NULL DEFAULT ''"},
+	{"sessions", "block_id", "INTEGER NOT NULL DEFAULT 0"},
+	{"blocks", "kind", "TEXT NOT NULL DEFAULT 'session'"},
+	{"blocks", "title", "TEXT NOT NULL DEFAULT ''"},
+	{"blocks", "location", "TEXT NOT NULL DEFAULT ''"},
+	{"classrooms", "capacity", "INTEGER NOT NULL DEFAULT 0"},
+	{"sessions", "audience", "INTEGER NOT NULL DEFAULT 0"},
+	{"sessions", "track_id", "INTEGER NOT NULL DEFAULT 0"},
+	{"sessions", "tags", "TEXT NOT NULL DEFAULT ''"},
+	{"sessions", "level", "TEXT NOT NULL DEFAULT ''"},
+	{"sessions", "programs", "TEXT NOT NULL DEFAULT ''"},
+	{"sessions", "length_minutes", "INTEGER NOT NULL DEFAULT 0"},
+	{"sessions", "block_span", "INTEGER NOT NULL DEFAULT 1"},
+	{"sessions", "series_id", "INTEGER NOT NULL DEFAULT 0"},
+	{"sessions", "series_part", "INTEGER NOT NULL DEFAULT 0"},
+	{"classrooms", "building", "TEXT NOT NULL DEFAULT ''"},
+	{"sessions", "repeat_of", "INTEGER NOT NULL DEFAULT 0"},
+	{"events", "time_zone", "TEXT NOT NULL DEFAULT ''"},
+	{"events", "time_format", "TEXT NOT NULL DEFAULT '24h'"},
+}
+
+func addLaterColumns(tx dbtx) error {
+	for _, c := range laterColumns {
+		if err := addColumnIfMissing(tx, c.table, c.column, c.decl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addColumnIfMissing(tx dbtx, table, column, decl string) error {
+	if hasColumn(tx, table, column) {
+		return nil
+	}
+	_, err := tx.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + decl)
+	return err
+}
+
+// upgradeLegacyTables moves a pre-events scheduler.db (one schedule per file)
+// into event #1 so nothing typed in last year is lost.
+func upgradeLegacyTables(tx dbtx) error {
+	if !tableExists(tx, "classrooms") || hasColumn(tx, "classrooms", "event_id") {
+		return nil
+	}
+	log.Println("Legacy database found → moving existing schedule into event #1")
+
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL DEFAULT '',
+			location TEXT NOT NULL DEFAULT '',
+			start_date TEXT NOT NULL DEFAULT '',
+			end_date TEXT NOT NULL DEFAULT ''
+		)`,
+		`INSERT OR IGNORE INTO events (id, name) VALUES (1, 'JUMPSTART')`,
+
+		`CREATE TABLE classrooms_new (
+			event_id INTEGER NOT NULL,
+			id INTEGER NOT NULL,
+			name TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY(event_id, id),
+			FOREIGN KEY(event_id) REFERENCES events(id) ON DELETE CASCADE
+		)`,
+		`INSERT INTO classrooms_new (event_id, id, name) SELECT 1, id, name FROM classrooms`,
+		`DROP TABLE classrooms`,
+		`ALTER TABLE classrooms_new RENAME TO classrooms`,
+
+		`CREATE TABLE blocks_new (
+			event_id INTEGER NOT NULL,
+			id INTEGER NOT NULL,
+			start_time TEXT NOT NULL,
+			end_time TEXT NOT NULL,
+			PRIMARY KEY(event_id, id),
+			FOREIGN KEY(event_id) REFERENCES events(id) ON DELETE CASCADE
+		)`,
+		`INSERT INTO blocks_new (event_id, id, start_time, end_time) SELECT 1, id, start_time, end_time FROM blocks`,
+		`DROP TABLE blocks`,
+		`ALTER TABLE blocks_new RENAME TO blocks`,
+
+		`ALTER TABLE sessions ADD COLUMN event_id INTEGER NOT NULL DEFAULT 1`,
+	}
+	for _, q := range stmts {
+		if _, err := tx.Exec(q); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func saveClassroomsToDB() { 
-	tx, _ := DB.Begin()
-	tx.Exec("DELETE FROM classrooms")
-	stmt, _ := tx.Prepare("INSERT INTO classrooms (id, name) VALUES (?, ?)")
-	for _, cl := range classroomsCache {
-		stmt.Exec(cl.ID, cl.Name)
+func tableExists(tx dbtx, name string) bool {
+	var n int
+	tx.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n)
+	return n > 0
+}
+
+func hasColumn(tx dbtx, table, column string) bool {
+	rows, err := tx.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return false
 	}
-	tx.Commit()
- }
-func saveSessionsToDB()   { 
-	tx, _ := DB.Begin()
-	tx.Exec("DELETE FROM sessions")
-	stmt, _ := tx.Prepare("INSERT INTO sessions (classroom_id, start_time, end_time, title, presenter, description) VALUES (?, ?, ?, ?, ?, ?)")
-	for classroomID, sessions := range sessionsCache {
-		for _, s := range sessions {
-			stmt.Exec(classroomID, s.StartTime, s.EndTime, s.Title, s.Presenter, s.Description)
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if rows.Scan(&name) == nil && name == column {
+			return true
 		}
 	}
-	tx.Commit()
- }
-func saveBlocksToDB()     { 
-	tx, _ := DB.Begin()
-	tx.Exec("DELETE FROM blocks")
-	stmt, _ := tx.Prepare("INSERT INTO blocks (id, start_time, end_time) VALUES (?, ?, ?)")
-	for _, b := range blocksCache {
-		stmt.Exec(b.ID, b.StartTime, b.EndTime)
+	return false
+}
+
+// saveEventToDB saves e to the event list the caches hold; if that is stale
+// the list is reloaded – caller holds mu.
+func saveEventToDB(e *Event) error {
+	rev, err := store.SaveEvent(e, eventsRevision)
+	if err := eventsSaved(rev, err); err != nil {
+		log.Printf("Failed to save event %q: %v", e.Name, err)
+		return err
+	}
+	return nil
+}
+func deleteEventFromDB(id int) error {
+	rev, err := store.DeleteEvent(id, eventsRevision)
+	if err := eventsSaved(rev, err); err != nil {
+		log.Printf("Failed to delete event #%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// eventsSaved is saved for the event list – caller holds mu.
+func eventsSaved(rev int, err error) error {
+	if errors.Is(err, ErrStale) {
+		loadEventsFromDB()
+	}
+	if err != nil {
+		return err
+	}
+	eventsRevision = rev
+	return nil
+}
+
+// saveTemplateToDB saves t, taken from the current event; if the caches it
+// was taken from are stale they are reloaded – caller holds mu.
+func saveTemplateToDB(t *ScheduleTemplate) error {
+	err := store.SaveTemplate(t, currentEventID, scheduleRevision)
+	if err := saved(scheduleRevision, err); err != nil {
+		log.Println("Failed to save template:", err)
+		return err
+	}
+	return nil
+}
+func deleteTemplateFromDB(id int) error {
+	if err := store.DeleteTemplate(id); err != nil {
+		log.Println("Failed to delete template:", err)
+		return err
+	}
+	return nil
+}
+// savePublicationToDB saves p, a version of the current event – caller
+// holds mu.
+func savePublicationToDB(p *Publication) error {
+	rev, err := store.SavePublication(p, scheduleRevision)
+	if err := saved(rev, err); err != nil {
+		log.Println("Failed to save publication:", err)
+		return err
+	}
+	return nil
+}
+func retirePublicationInDB(id int) error {
+	rev, err := store.RetirePublication(currentEventID, id, scheduleRevision)
+	if err := saved(rev, err); err != nil {
+		log.Println("Failed to retire publication:", err)
+		return err
+	}
+	return nil
+}
+
+func saveSetting(k, v string) error {
+	if err := store.SaveSetting(k, v); err != nil {
+		log.Printf("Failed to save setting %s: %v", k, err)
+		return err
 	}
-	tx.Commit()
+	return nil
 }
-func saveSetting(k, v string) {
-	DB.Exec("INSERT OR REPLACE INTO settings(key,value) VALUES(?,?)", k, v)
+
+// eventSetting reads one of the current event's settings. An event that
+// never saved it gets the value from before settings were kept per event.
+func eventSetting(k string) (string, error) {
+	val, err := store.EventSetting(currentEventID, k)
+	if err == sql.ErrNoRows {
+		return store.Setting(k)
+	}
+	return val, err
 }
 
+// dbPath is the database the server runs on, next to the binary.
+const dbPath = "scheduler.db"
+
 // Public function called from main.go
 func OpenDBAndLoadCaches() {
 	var err error
-	DB, err = sql.Open("sqlite", "scheduler.db")
+	DB, err = sql.Open("sqlite", dbPath)
 	if err != nil {
 		log.Fatal("Failed to open database:", err)
 	}
-
-	createTables()
+	if err := migrateDB(DB, dbPath); err != nil {
+		log.Fatal("Database upgrade failed: ", err)
+	}
+	if store, err = openStore(); err != nil {
+		log.Fatal("Failed to open the schedule store: ", err)
+	}
 	loadCaches() // This loads everything including defaults
 }
 
@@ -138,106 +564,315 @@ func OpenDBAndLoadCaches() {
 func loadCaches() {
 	mu.Lock()
 	defer mu.Unlock()
+	if err := ensureDefaultEvent(); err != nil { // ← first run: one event to hang everything on
+		log.Fatal("Failed to create the first event: ", err)
+	}
+	if err := reloadCaches(); err != nil {
+		log.Fatal("Failed to load the schedule: ", err)
+	}
+}
+
+// reloadCaches refills every cache for the event they hold – caller holds mu.
+func reloadCaches() error {
+	return loadEvent(currentEventID)
+}
+
+// loadEvent fills every cache for an event; 0, or one that no longer exists,
+// loads the live event. Everything is read before a cache changes, so on an
+// error they are all left as they were – caller holds mu.
+func loadEvent(id int) error {
+	eventsRev, events, err := loadEventList()
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return errors.New("there are no events")
+	}
+	live, err := liveEventSetting()
+	if err != nil {
+		return fmt.Errorf("loading the live event: %w", err)
+	}
+	if !hasEvent(events, live) {
+		live = events[0].ID
+	}
+	if !hasEvent(events, id) {
+		id = live
+	}
+	sc, rev, err := loadScheduleFromDB(id)
+	if err != nil {
+		return err
+	}
 
-	loadClassroomsFromDB()
-	loadSessionsFromDB()
-	loadBlocksFromDB()
+	auditBase = nil // loading is not an edit
+	eventsCache, eventsRevision = events, eventsRev
+	currentEventID, liveEventID = id, live
+	checkedAt = time.Now()
+	classroomsCache, blocksCache, sessionsCache = sc.Classrooms, sc.Blocks, sc.Sessions
+	presentersCache, tracksCache, seriesCache = sc.Presenters, sc.Tracks, sc.Series
+	scheduleRevision = rev
+	if len(classroomsCache) == 0 {
+		log.Println("No classrooms in DB – will be created when you save in /blocks")
+	}
+	useEventZone()
+	loadSettingsFromDB()      // ← session length, break time & day template
 	ensureDefaultBlocks()     // ← creates default blocks if none exist
-	loadSettingsFromDB()      // ← session length & break time
+	bindLegacySessions()      // ← sessions saved before block IDs existed
+	resetAuditBase()          // ← later saves are logged on /history
+	loadPublished()           // ← what the public pages show
+
+	log.Printf("Cache loaded: event #%d, %d classrooms, %d blocks, %d total sessions",
+		currentEventID, len(classroomsCache), len(blocksCache), countTotalSessions())
+	return nil
+}
+
+// switchEvent loads another event for the rest of the request – caller holds
+// mu, and eventMu on its own.
+func switchEvent(id int) bool {
+	if findEvent(id) == nil {
+		return false
+	}
+	if err := loadEvent(id); err != nil {
+		log.Printf("Failed to open event #%d: %v", id, err)
+		return false
+	}
+	return true
+}
 
-	log.Printf("Cache loaded: %d classrooms, %d blocks, %d total sessions",
-		len(classroomsCache), len(blocksCache), countTotalSessions())
+// liveEventSetting reads which event is live; before there was a live event
+// the one every page worked on was.
+func liveEventSetting() (int, error) {
+	val, err := store.Setting("live_event_id")
+	if err == sql.ErrNoRows {
+		val, err = store.Setting("current_event_id")
+	}
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	id, _ := strconv.Atoi(val)
+	return id, err
 }
 
 // Individual loaders
-func loadClassroomsFromDB() {
-	classroomsCache = make(map[int]*Classroom)
-	rows, err := DB.Query("SELECT id, name FROM classrooms ORDER BY id")
+
+// loadEventsFromDB refreshes eventsCache; on an error it is left as it was.
+func loadEventsFromDB() error {
+	rev, events, err := loadEventList()
 	if err != nil {
-		log.Fatal("Failed to load classrooms:", err)
+		log.Println("Failed to load events:", err)
+		return err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var c Classroom
-		if err := rows.Scan(&c.ID, &c.Name); err != nil {
-			log.Fatal(err)
-		}
-		classroomsCache[c.ID] = &c
+	eventsCache, eventsRevision = events, rev
+	return nil
+}
+
+// loadEventList reads the events and the revision of their list, that first
+// so a save made in between is not missed.
+func loadEventList() (int, []*Event, error) {
+	rev, err := store.Revision(eventList)
+	if err != nil {
+		return 0, nil, fmt.Errorf("loading the event list revision: %w", err)
 	}
-	if len(classroomsCache) == 0 {
-		log.Println("No classrooms in DB – will be created when you save in /blocks")
+	events, err := store.LoadEvents()
+	if err != nil {
+		return 0, nil, fmt.Errorf("loading events: %w", err)
 	}
+	return rev, events, nil
 }
 
-func loadSessionsFromDB() {
-	sessionsCache = make(map[int][]Session)
-	rows, err := DB.Query("SELECT classroom_id, start_time, end_time, title, presenter, description FROM sessions")
+// ensureDefaultEvent creates an event if there is none yet and makes the
+// newest live if none is – at startup, before the caches load.
+func ensureDefaultEvent() error {
+	rev, events, err := loadEventList()
 	if err != nil {
-		log.Fatal("Failed to load sessions:", err)
+		return err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var s Session
-		var cid int
-		if err := rows.Scan(&cid, &s.StartTime, &s.EndTime, &s.Title, &s.Presenter, &s.Description); err != nil {
-			log.Fatal(err)
+	if len(events) == 0 {
+		log.Println("No events found → creating default event")
+		year := time.Now().Year()
+		e := &Event{Name: "JUMPSTART " + strconv.Itoa(year), TimeFormat: Clock12}
+		if _, err := store.SaveEvent(e, rev); err != nil {
+			return err
+		}
+		events = append(events, e)
+	}
+	live, err := liveEventSetting()
+	if err != nil || hasEvent(events, live) {
+		return err
+	}
+	return saveSetting("live_event_id", strconv.Itoa(events[0].ID))
+}
+
+func hasEvent(events []*Event, id int) bool {
+	return slices.ContainsFunc(events, func(e *Event) bool { return e.ID == id })
+}
+
+func findEvent(id int) *Event {
+	for _, e := range eventsCache {
+		if e.ID == id {
+			return e
 		}
-		s.ClassroomID = cid
-		sessionsCache[cid] = append(sessionsCache[cid], s)
 	}
+	return nil
+}
+
+// loadScheduleFromDB reads everything SaveSchedule writes for an event and
+// the revision it is at. The revision is read first: if another instance
+// saves in between, the next save here is refused rather than writing over
+// what it did not see.
+func loadScheduleFromDB(eventID int) (sc Schedule, rev int, err error) {
+	if rev, err = store.Revision(eventID); err != nil {
+		return sc, 0, fmt.Errorf("loading the schedule revision: %w", err)
+	}
+	if sc.Classrooms, err = store.LoadClassrooms(eventID); err != nil {
+		return sc, 0, fmt.Errorf("loading classrooms: %w", err)
+	}
+	if sc.Presenters, err = store.LoadPresenters(eventID); err != nil {
+		return sc, 0, fmt.Errorf("loading presenters: %w", err)
+	}
+	if sc.Tracks, err = store.LoadTracks(eventID); err != nil {
+		return sc, 0, fmt.Errorf("loading tracks: %w", err)
+	}
+	if sc.Series, err = store.LoadSeries(eventID); err != nil {
+		return sc, 0, fmt.Errorf("loading series: %w", err)
+	}
+	if sc.Sessions, err = store.LoadSessions(eventID); err != nil {
+		return sc, 0, fmt.Errorf("loading sessions: %w", err)
+	}
+	if sc.Blocks, err = store.LoadBlocks(eventID); err != nil {
+		return sc, 0, fmt.Errorf("loading blocks: %w", err)
+	}
+	return sc, rev, nil
 }
 
-func loadBlocksFromDB() {
-	blocksCache = blocksCache[:0] // clear
-	rows, err := DB.Query("SELECT id, start_time, end_time FROM blocks ORDER BY id")
+// loadPublishedFromDB reads an event's live version, or with before set the
+// one that would be live without it. Nil if there is none.
+func loadPublishedFromDB(eventID, before int) (*Publication, error) {
+	p, err := store.LoadPublication(eventID, before)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
-		log.Fatal("Failed to load blocks:", err)
+		log.Println("Failed to load publication:", err)
+		return nil, err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var b Block
-		if err := rows.Scan(&b.ID, &b.StartTime, &b.EndTime); err != nil {
-			log.Fatal(err)
+	return p, nil
+}
+
+// loadPublicationsFromDB lists an event's versions, newest first, without
+// their schedules.
+func loadPublicationsFromDB(eventID, limit int) []Publication {
+	list, err := store.LoadPublications(eventID, limit)
+	if err != nil {
+		log.Println("Failed to load publications:", err)
+	}
+	return list
+}
+
+// loadTemplatesFromDB reads every template, newest first. Templates are not
+// cached – they are only needed on /templates.
+func loadTemplatesFromDB() []*ScheduleTemplate {
+	list, err := store.LoadTemplates()
+	if err != nil {
+		log.Println("Failed to load templates:", err)
+	}
+	return list
+}
+
+func findTemplateInDB(id int) *ScheduleTemplate {
+	for _, t := range loadTemplatesFromDB() {
+		if t.ID == id {
+			return t
 		}
-		blocksCache = append(blocksCache, b)
 	}
+	return nil
 }
 
 func ensureDefaultBlocks() {
 	if len(blocksCache) > 0 {
 		return // already have blocks
 	}
-	log.Println("No schedule found → creating default 5 blocks")
-	blocksCache = []Block{
-		{ID: 1, StartTime: "08:00", EndTime: "09:30"},
-		{ID: 2, StartTime: "09:40", EndTime: "11:10"},
-		{ID: 3, StartTime: "11:20", EndTime: "12:50"},
-		{ID: 4, StartTime: "13:30", EndTime: "15:00"},
-		{ID: 5, StartTime: "15:10", EndTime: "16:40"},
+	log.Println("No schedule found → generating a day from the day template")
+	blocks := generateDay(currentDayTemplate(), scheduleDays()[0])
+	for i := range blocks {
+		blocks[i].ID = i + 1
+	}
+	saveSchedule(context.Background(), Schedule{Blocks: blocks}) // logged if it fails; tried again next load
+}
+
+// bindLegacySessions gives sessions saved before block IDs existed the block
+// they were lined up with by position. Empty placeholders are dropped and
+// anything past the last block becomes unscheduled – caller holds mu.
+func bindLegacySessions() {
+	changed := false
+	sessions := copySessions(sessionsCache)
+	for cid, list := range sessions {
+		if cid == 0 {
+			continue
+		}
+		var kept []Session
+		for i, s := range list {
+			if s.BlockID != 0 {
+				kept = append(kept, s)
+				continue
+			}
+			changed = true
+			if i < len(blocksCache) && !blocksCache[i].Spanning() {
+				b := blocksCache[i]
+				if s.isEmpty() && s.Day == b.Day && s.StartTime == b.StartTime && s.EndTime == b.EndTime {
+					continue
+				}
+				s.BlockID = b.ID
+				kept = append(kept, s)
+			} else if !s.isEmpty() {
+				s.ClassroomID = 0
+				sessions[0] = append(sessions[0], s)
+			}
+		}
+		sessions[cid] = kept
+	}
+	if changed && saveSchedule(context.Background(), Schedule{Sessions: sessions}) == nil {
+		log.Println("Bound legacy sessions to block IDs")
+	}
+}
+
+func findBlock(id int) *Block {
+	for i := range blocksCache {
+		if blocksCache[i].ID == id {
+			return &blocksCache[i]
+		}
+	}
+	return nil
+}
 
+// findSession returns the session in a room's block, if any – caller holds mu.
+func findSession(cid, blockID int) *Session {
+	return draftSchedule().findSession(cid, blockID)
+}
+
+func (sc Schedule) findSession(cid, blockID int) *Session {
+	list := sc.Sessions[cid]
+	for i := range list {
+		if list[i].BlockID == blockID {
+			return &list[i]
+		}
 	}
-	saveBlocksToDB()
+	return nil
 }
 
 func loadSettingsFromDB() {
-	var val string
-	if err := DB.QueryRow("SELECT value FROM settings WHERE key = 'session_length_minutes'").Scan(&val); err == sql.ErrNoRows {
-		sessionLengthMinutes = 45
-		saveSetting("session_length_minutes", "45")
-	} else if err == nil {
+	sessionLengthMinutes, breakMinutes = 45, 15
+	if val, err := eventSetting("session_length_minutes"); err == nil {
 		if n, _ := strconv.Atoi(val); n >= 20 && n <= 300 {
 			sessionLengthMinutes = n
 		}
 	}
 
-	if err := DB.QueryRow("SELECT value FROM settings WHERE key = 'break_minutes'").Scan(&val); err == sql.ErrNoRows {
-		breakMinutes = 15
-		saveSetting("break_minutes", "15")
-	} else if err == nil {
+	if val, err := eventSetting("break_minutes"); err == nil {
 		if n, _ := strconv.Atoi(val); n >= 0 && n <= 120 {
 			breakMinutes = n
 		}
 	}
+	loadDayTemplate()
 }
 
 func countTotalSessions() int {
@@ -0,0 +1,232 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Events page – list, create and edit events
+func EventsHandler(w http.ResponseWriter, r *http.Request) {
+	mu.RLock()
+	list := append([]*Event(nil), eventsCache...)
+	current, live := currentEventID, liveEventID
+	mu.RUnlock()
+
+	data := struct {
+		Events       []*Event
+		CurrentID    int
+		LiveID       int
+		ClockFormats []ClockFormat
+		Zones        []string
+
+		Active    string
+		PageTitle string
+		Year      int
+		ExtraCSS  []string
+		Flash     string
+	}{
+		Events:       list,
+		CurrentID:    current,
+		LiveID:       live,
+		ClockFormats: clockFormats,
+		Zones:        commonZones,
+
+		Active:    "events",
+		PageTitle: "Events",
+		Year:      eventNow().Year(),
+		ExtraCSS:  []string{"events.css"},
+		Flash:     r.URL.Query().Get("saved"),
+	}
+
+	RenderTemplate(w, "events.html", data)
+}
+
+// Create (id empty) or update an event
+func EventsSaveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	r.ParseForm()
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		http.Redirect(w, r, "/events?saved=Event+name+is+required", http.StatusSeeOther)
+		return
+	}
+	startDate := strings.TrimSpace(r.FormValue("start_date"))
+	endDate := strings.TrimSpace(r.FormValue("end_date"))
+	if !validDate(startDate) || !validDate(endDate) {
+		http.Redirect(w, r, "/events?saved=Dates+must+be+YYYY-MM-DD", http.StatusSeeOther)
+		return
+	}
+	if startDate != "" && endDate != "" && endDate < startDate {
+		http.Redirect(w, r, "/events?saved=End+date+is+before+start+date", http.StatusSeeOther)
+		return
+	}
+	zone := strings.TrimSpace(r.FormValue("time_zone"))
+	if _, err := loadZone(zone); err != nil {
+		http.Redirect(w, r, "/events?saved="+url.QueryEscape("Unknown time zone "+zone+" – use a name like America/Chicago"), http.StatusSeeOther)
+		return
+	}
+	format := r.FormValue("time_format")
+	if !validClockFormat(format) {
+		format = Clock24
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	id, _ := strconv.Atoi(r.FormValue("id"))
+	e := &Event{} // a copy, so the cache only changes once saved
+	if old := findEvent(id); old != nil {
+		*e = *old
+	}
+	e.Name = name
+	e.Location = strings.TrimSpace(r.FormValue("location"))
+	e.StartDate = startDate
+	e.EndDate = endDate
+	e.TimeZone = zone
+	e.TimeFormat = format
+	if err := saveEventToDB(e); err != nil {
+		http.Redirect(w, r, "/events?saved="+url.QueryEscape(saveFailed(err)), http.StatusSeeOther)
+		return
+	}
+	log.Printf("Saved event #%d %q", e.ID, e.Name)
+	if err := loadEventsFromDB(); err != nil {
+		msg := "The event was saved, but the list could not be reloaded: " + err.Error()
+		http.Redirect(w, r, "/events?saved="+url.QueryEscape(msg), http.StatusSeeOther)
+		return
+	}
+	useEventZone()
+
+	if r.FormValue("select") == "1" {
+		pickEvent(w, e.ID)
+	}
+	http.Redirect(w, r, "/events?saved=Event+saved", http.StatusSeeOther)
+}
+
+// Switch the event this browser's pages work on; everyone else stays where
+// they are
+func EventSelectHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	id, _ := strconv.Atoi(r.FormValue("event_id"))
+
+	mu.RLock()
+	ok := findEvent(id) != nil
+	mu.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	pickEvent(w, id)
+
+	back := r.FormValue("back")
+	if back == "" || !strings.HasPrefix(back, "/") || strings.HasPrefix(back, "//") {
+		back = "/"
+	}
+	http.Redirect(w, r, back, http.StatusSeeOther)
+}
+
+// Make an event the one attendees see on the public pages
+func EventLiveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	id, _ := strconv.Atoi(r.FormValue("id"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	e := findEvent(id)
+	if e == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := saveSetting("live_event_id", strconv.Itoa(id)); err != nil {
+		http.Redirect(w, r, "/events?saved="+url.QueryEscape(saveFailed(err)), http.StatusSeeOther)
+		return
+	}
+	liveEventID = id
+	log.Printf("Event #%d %q is live", e.ID, e.Name)
+	http.Redirect(w, r, "/events?saved="+url.QueryEscape(e.Name+" is now on the public pages"), http.StatusSeeOther)
+}
+
+func EventDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	id, _ := strconv.Atoi(r.FormValue("id"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if id == currentEventID {
+		http.Redirect(w, r, "/events?saved=Switch+to+another+event+before+deleting+this+one", http.StatusSeeOther)
+		return
+	}
+	if id == liveEventID {
+		http.Redirect(w, r, "/events?saved=Make+another+event+live+before+deleting+this+one", http.StatusSeeOther)
+		return
+	}
+	if findEvent(id) == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := deleteEventFromDB(id); err != nil {
+		http.Redirect(w, r, "/events?saved="+url.QueryEscape(saveFailed(err)), http.StatusSeeOther)
+		return
+	}
+	log.Printf("Deleted event #%d", id)
+	if err := loadEventsFromDB(); err != nil {
+		msg := "The event was deleted, but the list could not be reloaded: " + err.Error()
+		http.Redirect(w, r, "/events?saved="+url.QueryEscape(msg), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/events?saved=Event+deleted", http.StatusSeeOther)
+}
+
+// pickEvent remembers in the browser which event its pages work on.
+func pickEvent(w http.ResponseWriter, id int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "event",
+		Value:    strconv.Itoa(id),
+		Path:     "/",
+		MaxAge:   365 * 24 * 60 * 60,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// requestEvent is the event r is for: the live one on the public pages,
+// unless they preview the draft, otherwise the one picked in the browser
+// while it exists.
+func requestEvent(r *http.Request) int {
+	mu.RLock()
+	defer mu.RUnlock()
+	public := r.URL.Path == "/" || strings.HasPrefix(r.URL.Path, "/classroom/")
+	if public && r.URL.Query().Get("draft") == "" {
+		return liveEventID
+	}
+	if c, err := r.Cookie("event"); err == nil {
+		if id, _ := strconv.Atoi(c.Value); findEvent(id) != nil {
+			return id
+		}
+	}
+	return liveEventID
+}
+
+func validDate(s string) bool {
+	if s == "" {
+		return true
+	}
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
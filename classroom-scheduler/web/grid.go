@@ -0,0 +1,265 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// GridRow is one block on /grid with a cell per classroom.
+type GridRow struct {
+	Block Block
+	Cells []GridCell
+}
+
+// GridCell is one room × block cell; Session is nil when it's free.
+// A multi-block session's cell spans Rows rows and hides the ones below.
+type GridCell struct {
+	ClassroomID int
+	Session     *Session
+	Rows        int
+	Covered     bool
+}
+
+// GridDay is one day's rows.
+type GridDay struct {
+	Day  string
+	Rows []GridRow
+}
+
+// Grid editor – rooms across, blocks down, the backlog on the side
+func GridHandler(w http.ResponseWriter, r *http.Request) {
+	mu.RLock()
+	rooms := sortedClassrooms(classroomsCache)
+	covered := coveredCells(sessionsCache)
+	var days []GridDay
+	for _, d := range scheduleDays() {
+		gd := GridDay{Day: d}
+		for _, b := range blocksCache {
+			if b.Day != d {
+				continue
+			}
+			row := GridRow{Block: b}
+			if !b.Spanning() {
+				for _, c := range rooms {
+					cell := GridCell{ClassroomID: c.ID, Rows: 1}
+					if s := findSession(c.ID, b.ID); s != nil {
+						cp := *s
+						cp.Override = sessionOverridden(cp)
+						cell.Session = &cp
+						cell.Rows = max(len(sessionBlocks(cp)), 1)
+					} else if _, ok := covered[cellKey(c.ID, b.ID)]; ok {
+						cell.Covered = true
+					}
+					row.Cells = append(row.Cells, cell)
+				}
+			}
+			gd.Rows = append(gd.Rows, row)
+		}
+		days = append(days, gd)
+	}
+	backlog := append([]Session(nil), sessionsCache[0]...)
+	conflicts := len(analyzeConflicts())
+	mu.RUnlock()
+
+	data := struct {
+		Rooms     []*Classroom
+		Days      []GridDay
+		MultiDay  bool
+		Backlog   []Session
+		Conflicts int
+
+		Active    string
+		PageTitle string
+		Year      int
+		ExtraCSS  []string
+		Flash     string
+	}{
+		Rooms:     rooms,
+		Days:      days,
+		MultiDay:  len(days) > 1,
+		Backlog:   backlog,
+		Conflicts: conflicts,
+
+		Active:    "grid",
+		PageTitle: "Schedule Grid",
+		Year:      eventNow().Year(),
+		ExtraCSS:  []string{"grid.css"},
+		Flash:     r.URL.Query().Get("saved"),
+	}
+	RenderTemplate(w, "grid.html", data)
+}
+
+// moveRequest is the body of POST /api/move. ClassroomID 0 sends the
+// session back to the backlog.
+type moveRequest struct {
+	SessionID   int `json:"session_id"`
+	ClassroomID int `json:"classroom_id"`
+	BlockID     int `json:"block_id"`
+}
+
+type moveResponse struct {
+	OK        bool   `json:"ok"`
+	Error     string `json:"error,omitempty"`
+	SwappedID int    `json:"swapped_id,omitempty"` // session that took the mover's old place
+	Conflicts int    `json:"conflicts"`
+}
+
+// Move one session into a cell or back to the backlog. A session already in
+// the target cell swaps places with it; multi-block sessions only move into
+// free cells.
+func MoveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	var req moveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, moveResponse{Error: "Bad request"})
+		return
+	}
+
+	mu.Lock()
+	var swapped int
+	var moveErr error
+	err := editSessions(r.Context(), func(sessions map[int][]Session) error {
+		before := seriesProblems(sessions, classroomsCache)
+		swapped, moveErr = moveSession(sessions, req.SessionID, req.ClassroomID, req.BlockID)
+		if moveErr == nil {
+			moveErr = newSeriesProblem(sessions, before)
+		}
+		return moveErr
+	})
+	if moveErr != nil {
+		mu.Unlock()
+		writeJSON(w, http.StatusConflict, moveResponse{Error: moveErr.Error()})
+		return
+	}
+	if err != nil {
+		mu.Unlock()
+		writeJSON(w, http.StatusInternalServerError, moveResponse{Error: saveFailed(err)})
+		return
+	}
+	conflicts := len(analyzeConflicts())
+	mu.Unlock()
+
+	log.Printf("Moved session #%d to %d_%d", req.SessionID, req.ClassroomID, req.BlockID)
+	writeJSON(w, http.StatusOK, moveResponse{OK: true, SwappedID: swapped, Conflicts: conflicts})
+}
+
+// moveSession puts a session in a room's block, or in the backlog when cid
+// is 0, and returns the ID of a session it swapped with – caller holds mu.
+func moveSession(sessions map[int][]Session, id, cid, blockID int) (int, error) {
+	from, idx := locateSession(sessions, id)
+	if idx < 0 {
+		return 0, errors.New("That session no longer exists – reload the page")
+	}
+	s := sessions[from][idx]
+
+	var b *Block
+	if cid != 0 {
+		if classroomsCache[cid] == nil {
+			return 0, errors.New("Unknown classroom")
+		}
+		b = findBlock(blockID)
+		if b == nil || b.Spanning() {
+			return 0, errors.New("Sessions can only go in session blocks")
+		}
+		if s.ClassroomID == cid && s.BlockID == blockID {
+			return 0, nil
+		}
+		run := blockRun(blocksCache, blockID, s.Span)
+		if len(run) < s.Span {
+			return 0, errors.New("This session needs " + strconv.Itoa(s.Span) + " blocks in a row from there")
+		}
+		for _, rb := range run {
+			o := sessionCovering(sessions, cid, rb.ID)
+			if o == nil || o.ID == id {
+				continue
+			}
+			if rb.ID != blockID || o.BlockID != blockID || s.Span > 1 || o.Span > 1 {
+				return 0, errors.New("\"" + sessionLabel(*o) + "\" is in the way – multi-block sessions only move into free cells")
+			}
+		}
+	} else if from == 0 {
+		return 0, nil
+	}
+
+	// Whoever holds the target cell goes where the mover came from
+	swapped := 0
+	if b != nil {
+		if other := (Schedule{Sessions: sessions}).findSession(cid, blockID); other != nil {
+			o := *other
+			removeSession(sessions, cid, o.ID)
+			placeSession(sessions, o, s.ClassroomID, findBlock(s.BlockID))
+			swapped = o.ID
+		}
+	}
+	removeSession(sessions, from, id)
+	placeSession(sessions, s, cid, b)
+	return swapped, nil
+}
+
+// placeSession adds a session to a room's block on the blocks' times, or to
+// the backlog when b is nil – caller holds mu.
+func placeSession(sessions map[int][]Session, s Session, cid int, b *Block) {
+	if b == nil || cid == 0 {
+		s.ClassroomID = 0
+		s.BlockID = 0
+		sessions[0] = append(sessions[0], s)
+		return
+	}
+	s.ClassroomID = cid
+	s.BlockID = b.ID
+	s.Day = b.Day
+	s.StartTime = b.StartTime
+	s.EndTime = b.EndTime
+	if end := spanEnd(b.ID, s.Span); end != "" {
+		s.EndTime = end
+	}
+	sessions[cid] = append(sessions[cid], s)
+	sortSessions(sessions[cid])
+}
+
+// locateSession finds a session by ID: its room (0 = backlog) and index,
+// or -1 – caller holds mu.
+func locateSession(sessions map[int][]Session, id int) (int, int) {
+	for cid, list := range sessions {
+		for i, s := range list {
+			if s.ID == id {
+				return cid, i
+			}
+		}
+	}
+	return 0, -1
+}
+
+// removeSession – caller holds mu.
+func removeSession(sessions map[int][]Session, cid, id int) {
+	list := sessions[cid]
+	for i := range list {
+		if list[i].ID == id {
+			sessions[cid] = append(list[:i:i], list[i+1:]...)
+			return
+		}
+	}
+}
+
+// newSeriesProblem fails a move that puts a series part out of order or
+// place – caller holds mu.
+func newSeriesProblem(sessions map[int][]Session, before map[int]string) error {
+	for id, msg := range seriesProblems(sessions, classroomsCache) {
+		if before[id] == "" {
+			return errors.New(msg)
+		}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,581 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Change is one field of one session, classroom or block that a save
+// changed. Field "" is the whole record: New holds it as JSON when it was
+// created, Old when it was deleted.
+type Change struct {
+	ID       int
+	BatchID  int
+	EventID  int
+	Entity   string // EntitySession, EntityClassroom or EntityBlock
+	EntityID int
+	Label    string // what it was called at the time, e.g. "Intro to Java"
+	Field    string
+	Old      string
+	New      string
+	Reverted bool
+	OldText  string // Old for people – display only, not stored
+	NewText  string // New for people – display only, not stored
+}
+
+// ChangeBatch is everything one request saved.
+type ChangeBatch struct {
+	ID      int
+	At      string // RFC 3339, UTC
+	User    string
+	Action  string
+	Changes []Change
+	When    string // At in the event's zone and format – display only
+}
+
+const (
+	EntitySession   = "session"
+	EntityClassroom = "classroom"
+	EntityBlock     = "block"
+)
+
+// historyLimit is how many saves /history shows.
+const historyLimit = 100
+
+// auditField reads and writes one logged field as text.
+type auditField[T any] struct {
+	Name string
+	Get  func(T) string
+	Set  func(*T, string)
+}
+
+func textField[T any](name string, p func(*T) *string) auditField[T] {
+	return auditField[T]{name, func(v T) string { return *p(&v) }, func(v *T, s string) { *p(v) = s }}
+}
+
+func numberField[T any](name string, p func(*T) *int) auditField[T] {
+	return auditField[T]{name,
+		func(v T) string { return strconv.Itoa(*p(&v)) },
+		func(v *T, s string) { *p(v), _ = strconv.Atoi(s) }}
+}
+
+func listField[T any](name string, p func(*T) *[]string) auditField[T] {
+	return auditField[T]{name,
+		func(v T) string { return strings.Join(*p(&v), ",") },
+		func(v *T, s string) { *p(v) = parseTags(s) }}
+}
+
+var sessionFields = []auditField[Session]{
+	numberField("room", func(s *Session) *int { return &s.ClassroomID }),
+	numberField("block", func(s *Session) *int { return &s.BlockID }),
+	numberField("span", func(s *Session) *int { return &s.Span }),
+	textField("day", func(s *Session) *string { return &s.Day }),
+	textField("start", func(s *Session) *string { return &s.StartTime }),
+	textField("end", func(s *Session) *string { return &s.EndTime }),
+	textField("title", func(s *Session) *string { return &s.Title }),
+	textField("presenter", func(s *Session) *string { return &s.Presenter }),
+	{"presenters", func(s Session) string { return joinIDs(s.PresenterIDs) }, func(s *Session, v string) { s.PresenterIDs = splitIDs(v) }},
+	textField("description", func(s *Session) *string { return &s.Description }),
+	numberField("audience", func(s *Session) *int { return &s.Audience }),
+	numberField("track", func(s *Session) *int { return &s.TrackID }),
+	listField("tags", func(s *Session) *[]string { return &s.Tags }),
+	textField("level", func(s *Session) *string { return &s.Level }),
+	{"programs", func(s Session) string { return strings.Join(s.Programs, ",") }, func(s *Session, v string) { s.Programs = parsePrograms(v) }},
+	numberField("length", func(s *Session) *int { return &s.Length }),
+	numberField("series", func(s *Session) *int { return &s.SeriesID }),
+	numberField("part", func(s *Session) *int { return &s.Part }),
+	numberField("repeat of", func(s *Session) *int { return &s.RepeatOf }),
+}
+
+var classroomFields = []auditField[Classroom]{
+	textField("name", func(c *Classroom) *string { return &c.Name }),
+	numberField("seats", func(c *Classroom) *int { return &c.Capacity }),
+	textField("building", func(c *Classroom) *string { return &c.Building }),
+}
+
+var blockFields = []auditField[Block]{
+	textField("day", func(b *Block) *string { return &b.Day }),
+	textField("start", func(b *Block) *string { return &b.StartTime }),
+	textField("end", func(b *Block) *string { return &b.EndTime }),
+	textField("kind", func(b *Block) *string { return &b.Kind }),
+	textField("title", func(b *Block) *string { return &b.Title }),
+	textField("location", func(b *Block) *string { return &b.Location }),
+}
+
+// auditState is the current event as it was last saved; a save logs what it
+// writes that differs from it.
+type auditState struct {
+	sessions   map[int]Session
+	classrooms map[int]Classroom
+	blocks     map[int]Block
+}
+
+// auditBase is nil while the caches are (re)loading – nothing is logged
+var auditBase *auditState
+
+// auditKey holds a change-making request's batch in its context; every save
+// the request makes is logged under it.
+type auditKey struct{}
+
+// auditBatchOf is the batch saves made for ctx are logged under, nil outside
+// a change-making request.
+func auditBatchOf(ctx context.Context) *ChangeBatch {
+	batch, _ := ctx.Value(auditKey{}).(*ChangeBatch)
+	return batch
+}
+
+// actionNames label the requests that save, for /history.
+var actionNames = map[string]string{
+	"/config/save":     "Edit Sessions",
+	"/config/merge":    "Edit Sessions (merged)",
+	"/blocks/save":     "Configure Blocks",
+	"/api/move":        "Schedule Grid",
+	"/pool/save":       "Session Pool",
+	"/pool/apply":      "Solver proposal",
+	"/series/save":     "Series",
+	"/series/delete":   "Series deleted",
+	"/templates/start": "New event from a template",
+	"/history/undo":    "Undo",
+	"/publish/discard": "Draft reset to the published version",
+}
+
+// History page – every save of the current event, newest first
+func HistoryHandler(w http.ResponseWriter, r *http.Request) {
+	mu.RLock()
+	batches, err := store.LoadHistory(currentEventID, historyLimit)
+	if err != nil {
+		log.Println("Failed to load history:", err)
+	}
+	for i := range batches {
+		b := &batches[i]
+		b.When = displayTime(b.At)
+		for j := range b.Changes {
+			describeChange(&b.Changes[j])
+		}
+	}
+	mu.RUnlock()
+
+	data := struct {
+		Batches []ChangeBatch
+		Editor  string
+
+		Active    string
+		PageTitle string
+		Year      int
+		ExtraCSS  []string
+		Flash     string
+	}{
+		Batches: batches,
+		Editor:  editorName(r),
+
+		Active:    "history",
+		PageTitle: "Change History",
+		Year:      eventNow().Year(),
+		ExtraCSS:  []string{"history.css"},
+		Flash:     r.URL.Query().Get("saved"),
+	}
+	RenderTemplate(w, "history.html", data)
+}
+
+// Undo one change (change=ID) or a whole save (batch=ID). Changes made since
+// to the same field are not overwritten – the undo fails instead.
+func HistoryUndoHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	changeID, _ := strconv.Atoi(r.FormValue("change"))
+	batchID, _ := strconv.Atoi(r.FormValue("batch"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	changes, err := store.LoadChanges(currentEventID, changeID, batchID)
+	if err != nil {
+		http.Redirect(w, r, "/history?saved="+url.QueryEscape("Could not undo: "+err.Error()), http.StatusSeeOther)
+		return
+	}
+	if len(changes) == 0 {
+		http.Redirect(w, r, "/history?saved=Nothing+left+to+undo", http.StatusSeeOther)
+		return
+	}
+
+	// All or nothing
+	if err := editSchedule(r.Context(), func(sc *Schedule) error { return undoChanges(sc, changes) }); err != nil {
+		http.Redirect(w, r, "/history?saved="+url.QueryEscape("Could not undo: "+err.Error()), http.StatusSeeOther)
+		return
+	}
+	if err := store.MarkReverted(changes); err != nil {
+		msg := "Undone, but the changes are not marked as undone on this page: " + err.Error()
+		http.Redirect(w, r, "/history?saved="+url.QueryEscape(msg), http.StatusSeeOther)
+		return
+	}
+
+	log.Printf("Undid %d change(s)", len(changes))
+	http.Redirect(w, r, "/history?saved="+url.QueryEscape("Undone – "+strconv.Itoa(len(changes))+" change(s) put back"), http.StatusSeeOther)
+}
+
+// Remember who is editing from this browser
+func HistoryEditorHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     "editor",
+		Value:    url.QueryEscape(strings.TrimSpace(r.FormValue("name"))),
+		Path:     "/",
+		MaxAge:   365 * 24 * 60 * 60,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/history?saved=Thanks+–+your+changes+are+logged+under+that+name", http.StatusSeeOther)
+}
+
+// editorName is who a request's changes are logged under: the basic-auth
+// user, the name set on /history, or the address they came from.
+func editorName(r *http.Request) string {
+	if u, _, ok := r.BasicAuth(); ok && u != "" {
+		return u
+	}
+	if c, err := r.Cookie("editor"); err == nil {
+		if name, err := url.QueryUnescape(c.Value); err == nil && name != "" {
+			return name
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "Unknown (" + host + ")"
+}
+
+// resetAuditBase starts logging against the caches as they are now –
+// caller holds mu.
+func resetAuditBase() {
+	auditBase = &auditState{currentSessions(), currentClassrooms(), currentBlocks()}
+}
+
+func currentSessions() map[int]Session     { return sessionRecords(sessionsCache) }
+func currentClassrooms() map[int]Classroom { return classroomRecords(classroomsCache) }
+func currentBlocks() map[int]Block         { return blockRecords(blocksCache) }
+
+func sessionRecords(sessions map[int][]Session) map[int]Session {
+	m := make(map[int]Session)
+	for cid, list := range sessions {
+		for _, s := range list {
+			s.ClassroomID = cid
+			m[s.ID] = s
+		}
+	}
+	return m
+}
+
+func classroomRecords(rooms map[int]*Classroom) map[int]Classroom {
+	m := make(map[int]Classroom, len(rooms))
+	for id, c := range rooms {
+		m[id] = *c
+	}
+	return m
+}
+
+func blockRecords(blocks []Block) map[int]Block {
+	m := make(map[int]Block, len(blocks))
+	for _, b := range blocks {
+		m[b.ID] = b
+	}
+	return m
+}
+
+// auditChanges lists what saving s changes from the last save – caller
+// holds mu.
+func auditChanges(s Schedule) []Change {
+	if auditBase == nil {
+		return nil
+	}
+	var changes []Change
+	if s.Classrooms != nil {
+		changes = append(changes, diffRecords(EntityClassroom, auditBase.classrooms, classroomRecords(s.Classrooms), classroomFields, classroomLabel)...)
+	}
+	if s.Blocks != nil {
+		changes = append(changes, diffRecords(EntityBlock, auditBase.blocks, blockRecords(s.Blocks), blockFields, blockLabel)...)
+	}
+	if s.Sessions != nil {
+		changes = append(changes, diffRecords(EntitySession, auditBase.sessions, sessionRecords(s.Sessions), sessionFields, sessionLabel)...)
+	}
+	return changes
+}
+
+// advance makes s, once saved, what the next save is compared with –
+// caller holds mu.
+func (a *auditState) advance(s Schedule) {
+	if a == nil {
+		return
+	}
+	if s.Classrooms != nil {
+		a.classrooms = classroomRecords(s.Classrooms)
+	}
+	if s.Blocks != nil {
+		a.blocks = blockRecords(s.Blocks)
+	}
+	if s.Sessions != nil {
+		a.sessions = sessionRecords(s.Sessions)
+	}
+}
+
+func classroomLabel(c Classroom) string { return c.Name }
+
+func blockLabel(b Block) string {
+	if b.Spanning() {
+		return b.Label() + " " + timeRange(b.Day, b.StartTime, b.EndTime)
+	}
+	return timeRange(b.Day, b.StartTime, b.EndTime)
+}
+
+// diffRecords lists a Change for every created, deleted or edited record.
+func diffRecords[T any](entity string, before, after map[int]T, fields []auditField[T], label func(T) string) []Change {
+	var changes []Change
+	add := func(id int, v T, field, old, new string) {
+		changes = append(changes, Change{
+			EventID: currentEventID, Entity: entity, EntityID: id, Label: label(v),
+			Field: field, Old: old, New: new,
+		})
+	}
+	for _, id := range sortedKeys(after) {
+		a := after[id]
+		b, ok := before[id]
+		if !ok {
+			add(id, a, "", "", recordJSON(a))
+			continue
+		}
+		for _, f := range fields {
+			if o, n := f.Get(b), f.Get(a); o != n {
+				add(id, a, f.Name, o, n)
+			}
+		}
+	}
+	for _, id := range sortedKeys(before) {
+		if _, ok := after[id]; !ok {
+			add(id, before[id], "", recordJSON(before[id]), "")
+		}
+	}
+	return changes
+}
+
+func sortedKeys[T any](m map[int]T) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func recordJSON(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// undoChanges puts back changes in sc, newest first.
+func undoChanges(sc *Schedule, changes []Change) error {
+	blocks := append([]Block(nil), sc.Blocks...)
+	blocksTouched := false
+	for i := len(changes) - 1; i >= 0; i-- {
+		c := changes[i]
+		var err error
+		switch c.Entity {
+		case EntitySession:
+			err = undoSession(sc, c)
+		case EntityClassroom:
+			err = undoClassroom(sc, c)
+		case EntityBlock:
+			err = undoBlock(&blocks, c)
+			blocksTouched = true
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if blocksTouched {
+		// Sessions on a block's times follow it back, like a /blocks save
+		sortBlocks(blocks)
+		rebindSessions(sc, blocks)
+		sc.Blocks = blocks
+	}
+	return nil
+}
+
+func undoSession(sc *Schedule, c Change) error {
+	cid, idx := locateSession(sc.Sessions, c.EntityID)
+	switch {
+	case c.Field == "" && c.New != "": // created
+		if idx >= 0 {
+			removeSession(sc.Sessions, cid, c.EntityID)
+		}
+	case c.Field == "": // deleted
+		if idx >= 0 {
+			return errors.New(c.Label + " already exists again")
+		}
+		var s Session
+		if err := json.Unmarshal([]byte(c.Old), &s); err != nil {
+			return errors.New("the saved copy of " + c.Label + " is unreadable")
+		}
+		if sc.Classrooms[s.ClassroomID] == nil {
+			s.ClassroomID, s.BlockID = 0, 0
+		}
+		sc.Sessions[s.ClassroomID] = append(sc.Sessions[s.ClassroomID], s)
+	default:
+		if idx < 0 {
+			return errors.New(c.Label + " no longer exists")
+		}
+		s := sc.Sessions[cid][idx]
+		s.ClassroomID = cid
+		if err := undoField(&s, c, sessionFields); err != nil {
+			return err
+		}
+		if s.ClassroomID != cid {
+			removeSession(sc.Sessions, cid, s.ID)
+			sc.Sessions[s.ClassroomID] = append(sc.Sessions[s.ClassroomID], s)
+		} else {
+			sc.Sessions[cid][idx] = s
+		}
+	}
+	return nil
+}
+
+func undoClassroom(sc *Schedule, c Change) error {
+	room := sc.Classrooms[c.EntityID]
+	switch {
+	case c.Field == "" && c.New != "": // created – its sessions wait unscheduled
+		if room == nil {
+			return nil
+		}
+		for _, s := range sc.Sessions[c.EntityID] {
+			s.ClassroomID, s.BlockID = 0, 0
+			sc.Sessions[0] = append(sc.Sessions[0], s)
+		}
+		delete(sc.Sessions, c.EntityID)
+		delete(sc.Classrooms, c.EntityID)
+	case c.Field == "": // deleted
+		if room != nil {
+			return errors.New(c.Label + " already exists again")
+		}
+		var restored Classroom
+		if err := json.Unmarshal([]byte(c.Old), &restored); err != nil {
+			return errors.New("the saved copy of " + c.Label + " is unreadable")
+		}
+		sc.Classrooms[restored.ID] = &restored
+	default:
+		if room == nil {
+			return errors.New(c.Label + " no longer exists")
+		}
+		return undoField(room, c, classroomFields)
+	}
+	return nil
+}
+
+func undoBlock(blocks *[]Block, c Change) error {
+	idx := -1
+	for i, b := range *blocks {
+		if b.ID == c.EntityID {
+			idx = i
+		}
+	}
+	switch {
+	case c.Field == "" && c.New != "": // created
+		if idx >= 0 {
+			*blocks = append((*blocks)[:idx:idx], (*blocks)[idx+1:]...)
+		}
+	case c.Field == "": // deleted
+		if idx >= 0 {
+			return errors.New("block " + c.Label + " already exists again")
+		}
+		var b Block
+		if err := json.Unmarshal([]byte(c.Old), &b); err != nil {
+			return errors.New("the saved copy of block " + c.Label + " is unreadable")
+		}
+		*blocks = append(*blocks, b)
+	default:
+		if idx < 0 {
+			return errors.New("block " + c.Label + " no longer exists")
+		}
+		return undoField(&(*blocks)[idx], c, blockFields)
+	}
+	return nil
+}
+
+// undoField sets a field back if nothing changed it since.
+func undoField[T any](v *T, c Change, fields []auditField[T]) error {
+	for _, f := range fields {
+		if f.Name != c.Field {
+			continue
+		}
+		if f.Get(*v) != c.New {
+			return errors.New(c.Label + ": " + c.Field + " has been changed again since")
+		}
+		f.Set(v, c.Old)
+		return nil
+	}
+	return errors.New("unknown field " + c.Field)
+}
+
+// describeChange fills OldText and NewText – caller holds mu.
+func describeChange(c *Change) {
+	c.OldText, c.NewText = fieldText(c.Entity, c.Field, c.Old), fieldText(c.Entity, c.Field, c.New)
+}
+
+func fieldText(entity, field, v string) string {
+	if v == "" {
+		return "–"
+	}
+	switch {
+	case field == "start" || field == "end":
+		return displayClock(v)
+	case field == "day":
+		return dayLabel(v)
+	case entity == EntitySession && field == "room":
+		id, _ := strconv.Atoi(v)
+		return roomName(id)
+	case entity == EntitySession && field == "track":
+		id, _ := strconv.Atoi(v)
+		if t := findTrack(id); t != nil {
+			return t.Name
+		}
+	case entity == EntitySession && field == "presenters":
+		var names []string
+		for _, id := range splitIDs(v) {
+			if p := findPresenter(id); p != nil {
+				names = append(names, p.Name)
+			}
+		}
+		if len(names) > 0 {
+			return strings.Join(names, ", ")
+		}
+	}
+	return v
+}
+
+func joinIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+func splitIDs(s string) []int {
+	var ids []int
+	for _, p := range strings.Split(s, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(p)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
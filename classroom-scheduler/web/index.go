@@ -2,8 +2,6 @@ package web
 
 import (
 	"net/http"
-	"sort"
-	"time"
 )
 
 func IndexHandler(w http.ResponseWriter, r *http.Request) {
@@ -13,16 +11,36 @@ func IndexHandler(w http.ResponseWriter, r *http.Request) {
     }
 
     mu.RLock()
-    list := make([]*Classroom, 0, len(classroomsCache))
-    for _, c := range classroomsCache {
-        list = append(list, c)
+    sc := publicSchedule(r)
+    list := sortedClassrooms(sc.Classrooms)
+    filter := readFilter(r)
+
+    // Each room's sessions grouped by day; all-rooms blocks are listed once on top
+    days := make(map[int][]SessionDay, len(list))
+    for _, c := range list {
+        var sess []Session
+        for _, s := range roomSchedule(sc, c.ID) {
+            if s.Spanning == nil && filter.Match(s) {
+                sess = append(sess, s)
+            }
+        }
+        days[c.ID] = groupSessionsByDay(sess)
+    }
+    var spanning []Block
+    for _, b := range sc.Blocks {
+        if b.Spanning() {
+            spanning = append(spanning, b)
+        }
     }
-    sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+    multiDay := len(sc.days()) > 1
     mu.RUnlock()
 
     data := struct {
         Classrooms []*Classroom
-        Sessions   map[int][]Session
+        Days       map[int][]SessionDay
+        Spanning   []Block
+        MultiDay   bool
+        Filter     Filter
 
         // Layout fields
         Active     string
@@ -32,11 +50,14 @@ func IndexHandler(w http.ResponseWriter, r *http.Request) {
         Flash      string
     }{
         Classrooms: list,
-        Sessions:   sessionsCache,
+        Days:       days,
+        Spanning:   spanning,
+        MultiDay:   multiDay,
+        Filter:     filter,
 
         Active:    "home",
         PageTitle: "Home",
-        Year:      time.Now().Year(),
+        Year:      eventNow().Year(),
         ExtraCSS:  []string{"index.css"},
         Flash:     "",
     }
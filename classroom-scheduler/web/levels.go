@@ -0,0 +1,75 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Audience levels. "" means the session suits everyone.
+const (
+	LevelRookie       = "rookie"
+	LevelIntermediate = "intermediate"
+	LevelVeteran      = "veteran"
+)
+
+var sessionLevels = []string{LevelRookie, LevelIntermediate, LevelVeteran}
+
+// programs are the FIRST programs a session can be aimed at. None picked
+// means the session is for every program.
+var programs = []string{"FRC", "FTC", "FLL"}
+
+func validLevel(level string) bool {
+	for _, l := range sessionLevels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+func validProgram(program string) bool {
+	for _, p := range programs {
+		if p == program {
+			return true
+		}
+	}
+	return false
+}
+
+// levelLabel is what attendees see on a level badge.
+func levelLabel(level string) string {
+	switch level {
+	case LevelRookie:
+		return "Rookie"
+	case LevelIntermediate:
+		return "Intermediate"
+	case LevelVeteran:
+		return "Veteran"
+	}
+	return "All levels"
+}
+
+// formPrograms reads program checkboxes, in the order of programs.
+func formPrograms(r *http.Request, name string) []string {
+	var out []string
+	for _, p := range programs {
+		for _, v := range r.Form[name] {
+			if v == p {
+				out = append(out, p)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// parsePrograms reads the comma-separated programs column.
+func parsePrograms(raw string) []string {
+	var out []string
+	for _, p := range strings.Split(raw, ",") {
+		if validProgram(p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -2,11 +2,83 @@
 package web
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
+// AuditMiddleware gives each change-making (POST) request its own batch, so
+// that everything it saves is logged on /history together, under the right
+// editor.
+func AuditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		action, ok := actionNames[r.URL.Path]
+		if !ok {
+			action = r.URL.Path
+		}
+		batch := &ChangeBatch{At: time.Now().UTC().Format(time.RFC3339), User: editorName(r), Action: action}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), auditKey{}, batch)))
+	})
+}
+
+// eventMu keeps the event in the caches from changing under a request:
+// requests for that event share it, one for another event takes it alone.
+var eventMu sync.RWMutex
+
+// exclusivePaths load another event part way through, so they run alone.
+var exclusivePaths = map[string]bool{
+	"/templates/start": true,
+}
+
+// EventMiddleware has the event a request is for in the caches while it
+// runs (see requestEvent), as it was last saved by any instance. Requests
+// for the event already there run side by side; one for another event, or
+// one that finds the caches stale, waits for them, loads its own and runs
+// alone.
+func EventMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/static/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !exclusivePaths[r.URL.Path] {
+			eventMu.RLock()
+			mu.Lock()
+			loaded, stale := currentEventID, cachesStale()
+			mu.Unlock()
+			if requestEvent(r) == loaded && !stale {
+				defer eventMu.RUnlock()
+				next.ServeHTTP(w, r)
+				return
+			}
+			eventMu.RUnlock()
+		}
+
+		eventMu.Lock()
+		defer eventMu.Unlock()
+		id := requestEvent(r)
+		mu.Lock()
+		var err error
+		if id != currentEventID || cachesStale() {
+			err = loadEvent(id)
+		}
+		mu.Unlock()
+		if err != nil {
+			log.Printf("Failed to load event #%d: %v", id, err)
+			http.Error(w, "The event could not be loaded: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
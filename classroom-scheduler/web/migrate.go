@@ -0,0 +1,231 @@
+package web
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// dbtx is what both *sql.DB and *sql.Tx offer, so the schema helpers work
+// inside a migration's transaction.
+type dbtx interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// migration is one step in the life of the schema. Steps run in order, each
+// in its own transaction, and are recorded in schema_version so none runs
+// twice.
+type migration struct {
+	Version int
+	Name    string
+	SQL     string              // plain statements, or...
+	Up      func(tx dbtx) error // ...Go, for anything SQL alone can't do
+}
+
+// migrations – append only. A step that has shipped is never edited or
+// renumbered; fix it with a new one. Steps 1–3 are the schema as it was
+// before versioning and check before they change anything, because older
+// databases have no record of what already ran.
+var migrations = []migration{
+	{Version: 1, Name: "Move a pre-events database into event #1", Up: upgradeLegacyTables},
+	{Version: 2, Name: "Create tables", Up: createTables},
+	{Version: 3, Name: "Add columns from later releases", Up: addLaterColumns},
+	{Version: 4, Name: "Keep session length and break per event", SQL: `
+		CREATE TABLE IF NOT EXISTS event_settings (
+			event_id INTEGER NOT NULL,
+			key TEXT NOT NULL,
+			value TEXT,
+			PRIMARY KEY(event_id, key)
+		);`},
+	{Version: 5, Name: "Count schedule saves", SQL: `
+		CREATE TABLE IF NOT EXISTS schedule_revisions (
+			event_id INTEGER PRIMARY KEY,
+			revision INTEGER NOT NULL DEFAULT 0
+		);`},
+}
+
+const schemaVersionSQL = `
+	CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL DEFAULT '',
+		applied_at TEXT NOT NULL DEFAULT ''  -- RFC 3339, UTC
+	);`
+
+func latestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// schemaVersion is the last migration applied to db; 0 for a new database
+// or one from before versioning.
+func schemaVersion(db dbtx) int {
+	if !tableExists(db, "schema_version") {
+		return 0
+	}
+	var v int
+	db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&v)
+	return v
+}
+
+// migrateDB brings the database at path up to the latest version, backing
+// it up first if it holds anything.
+func migrateDB(db *sql.DB, path string) error {
+	current := schemaVersion(db)
+	if current > latestVersion() {
+		return fmt.Errorf("%s is at schema version %d, but this build only knows up to %d – run a newer build", path, current, latestVersion())
+	}
+	if current == latestVersion() {
+		return nil
+	}
+
+	backup := ""
+	if tableExists(db, "events") || tableExists(db, "classrooms") {
+		var err error
+		if backup, err = backupDB(db, path, current); err != nil {
+			return fmt.Errorf("backup before upgrading failed, nothing was changed: %w", err)
+		}
+		log.Printf("Database is at schema version %d of %d → backed up to %s", current, latestVersion(), backup)
+	}
+
+	if _, err := db.Exec(schemaVersionSQL); err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := applyMigration(db, m); err != nil {
+			err = fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+			if backup != "" {
+				err = fmt.Errorf("%w – %s has the database as it was before the upgrade", err, backup)
+			}
+			return err
+		}
+		log.Printf("Applied migration %d: %s", m.Version, m.Name)
+	}
+	return nil
+}
+
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // no-op once committed
+
+	if m.SQL != "" {
+		if _, err := tx.Exec(m.SQL); err != nil {
+			return err
+		}
+	}
+	if m.Up != nil {
+		if err := m.Up(tx); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)",
+		m.Version, m.Name, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// backupDB copies the whole database next to it, named after the version it
+// is at, e.g. scheduler.db.v4-20260301-081500.bak.
+func backupDB(db *sql.DB, path string, version int) (string, error) {
+	name := fmt.Sprintf("%s.v%d-%s.bak", path, version, time.Now().Format("20060102-150405"))
+	if _, err := db.Exec("VACUUM INTO ?", name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// MigrateCommand runs `scheduler migrate status|up [database]` and returns
+// the exit code. status changes nothing and exits 1 while migrations are
+// pending, so it can be run against a copy before event day.
+func MigrateCommand(args []string) int {
+	if len(args) == 0 || len(args) > 2 || (args[0] != "status" && args[0] != "up") {
+		fmt.Fprintln(os.Stderr, "usage: scheduler migrate status|up [database]")
+		return 2
+	}
+	path := dbPath
+	if len(args) == 2 {
+		path = args[1]
+	}
+	if _, err := os.Stat(path); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if args[0] == "up" {
+		db, err := sql.Open("sqlite", path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer db.Close()
+		if err := migrateDB(db, path); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("%s: schema version %d – up to date\n", path, schemaVersion(db))
+		return 0
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer db.Close()
+	return printMigrationStatus(db, path)
+}
+
+func printMigrationStatus(db *sql.DB, path string) int {
+	current := schemaVersion(db)
+	applied := make(map[int]string)
+	if rows, err := db.Query("SELECT version, applied_at FROM schema_version"); err == nil {
+		for rows.Next() {
+			var v int
+			var at string
+			if rows.Scan(&v, &at) == nil {
+				applied[v] = at
+			}
+		}
+		rows.Close()
+	}
+
+	pending := 0
+	for _, m := range migrations {
+		if m.Version > current {
+			pending++
+		}
+	}
+	switch {
+	case current > latestVersion():
+		fmt.Printf("%s: schema version %d – newer than this build (%d)\n", path, current, latestVersion())
+		return 1
+	case pending == 0:
+		fmt.Printf("%s: schema version %d – up to date\n", path, current)
+	case current == 0:
+		fmt.Printf("%s: no schema version yet – %d migration(s) run on next start\n", path, pending)
+	default:
+		fmt.Printf("%s: schema version %d of %d – %d migration(s) run on next start\n", path, current, latestVersion(), pending)
+	}
+	for _, m := range migrations {
+		state := "pending"
+		if at, ok := applied[m.Version]; ok {
+			state = "applied " + at
+		} else if m.Version <= current {
+			state = "applied"
+		}
+		fmt.Printf("  %3d  %-45s %s\n", m.Version, m.Name, state)
+	}
+	if pending > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,213 @@
+package web
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// legacySchema is the database as the scheduler wrote it before events and
+// schema versions.
+const legacySchema = `
+	CREATE TABLE classrooms (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		classroom_id INTEGER,
+		start_time TEXT NOT NULL,
+		end_time TEXT NOT NULL,
+		title TEXT NOT NULL,
+		presenter TEXT NOT NULL,
+		description TEXT,
+		FOREIGN KEY(classroom_id) REFERENCES classrooms(id) ON DELETE CASCADE
+	);
+	CREATE TABLE blocks (
+		id INTEGER PRIMARY KEY,
+		start_time TEXT NOT NULL,
+		end_time TEXT NOT NULL
+	);
+	CREATE TABLE settings (
+		key TEXT PRIMARY KEY,
+		value TEXT
+	);
+	INSERT INTO classrooms (id, name) VALUES (1, 'Room 101'), (2, 'Gym');
+	INSERT INTO blocks (id, start_time, end_time) VALUES (1, '09:00', '09:45'), (2, '10:00', '10:45');
+	INSERT INTO sessions (classroom_id, start_time, end_time, title, presenter, description)
+		VALUES (1, '09:00', '09:45', 'Knots', 'Ada', 'Bring rope'), (2, '10:00', '10:45', 'Relay', 'Grace', '');
+	INSERT INTO settings (key, value) VALUES ('session_length', '45');`
+
+func TestMigrateDB(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		setup      func(t *testing.T, db *sql.DB, path string)
+		wantBackup bool // named after the version the database was at
+		wantErr    bool
+	}{
+		{
+			name:  "new database",
+			setup: func(t *testing.T, db *sql.DB, path string) {},
+		},
+		{
+			name: "pre-events database",
+			setup: func(t *testing.T, db *sql.DB, path string) {
+				if _, err := db.Exec(legacySchema); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantBackup: true,
+		},
+		{
+			name: "up to date",
+			setup: func(t *testing.T, db *sql.DB, path string) {
+				if err := migrateDB(db, path); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "one migration behind",
+			setup: func(t *testing.T, db *sql.DB, path string) {
+				if err := migrateDB(db, path); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := db.Exec("DELETE FROM schema_version WHERE version = ?", latestVersion()); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantBackup: true,
+		},
+		{
+			name: "newer than this build",
+			setup: func(t *testing.T, db *sql.DB, path string) {
+				if err := migrateDB(db, path); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := db.Exec("INSERT INTO schema_version (version, name) VALUES (?, 'From the future')", latestVersion()+1); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "scheduler.db")
+			db, err := sql.Open("sqlite", path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			tc.setup(t, db, path)
+			before, _ := filepath.Glob(path + ".*.bak")
+			from := schemaVersion(db)
+
+			err = migrateDB(db, path)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("migrated, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v := schemaVersion(db); v != latestVersion() {
+				t.Errorf("schema version %d, want %d", v, latestVersion())
+			}
+
+			after, _ := filepath.Glob(path + ".*.bak")
+			var got string
+			if len(after) > len(before) {
+				got = after[len(after)-1]
+			}
+			if !tc.wantBackup && got != "" {
+				t.Errorf("backed up to %s, want no backup", got)
+			}
+			if want := fmt.Sprintf("%s.v%d-*.bak", path, from); tc.wantBackup {
+				if ok, _ := filepath.Match(want, got); !ok {
+					t.Errorf("backed up to %q, want %s", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrateDBLegacy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scheduler.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(legacySchema); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrateDB(db, path); err != nil {
+		t.Fatal(err)
+	}
+
+	st := NewSQLiteStore(db)
+	events, err := st.LoadEvents()
+	if err != nil || len(events) != 1 || events[0].ID != 1 {
+		t.Fatalf("events: got %v, %v, want event #1 only", events, err)
+	}
+
+	rooms, err := st.LoadClassrooms(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range sortedClassrooms(rooms) {
+		names = append(names, c.Name)
+	}
+	if want := []string{"Room 101", "Gym"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("rooms: got %q, want %q", names, want)
+	}
+
+	blocks, err := st.LoadBlocks(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var times []string
+	for _, b := range blocks {
+		times = append(times, b.StartTime+"-"+b.EndTime)
+	}
+	if want := []string{"09:00-09:45", "10:00-10:45"}; !reflect.DeepEqual(times, want) {
+		t.Errorf("blocks: got %q, want %q", times, want)
+	}
+
+	sessions, err := st.LoadSessions(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[int]string)
+	for cid, list := range sessions {
+		for _, s := range list {
+			got[cid] += s.Title + " by " + s.Presenter
+		}
+	}
+	if want := map[int]string{1: "Knots by Ada", 2: "Relay by Grace"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sessions: got %v, want %v", got, want)
+	}
+
+	// The backup is the database as it was, still without events
+	backups, _ := filepath.Glob(path + ".v0-*.bak")
+	if len(backups) != 1 {
+		t.Fatalf("backups: got %q, want one", backups)
+	}
+	old, err := sql.Open("sqlite", backups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old.Close()
+	var n int
+	if err := old.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&n); err != nil || n != 2 {
+		t.Errorf("backup sessions: got %d, %v, want 2", n, err)
+	}
+	if tableExists(old, "events") || hasColumn(old, "classrooms", "event_id") {
+		t.Error("backup was taken after the upgrade")
+	}
+}
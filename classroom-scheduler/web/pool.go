@@ -0,0 +1,303 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"scheduler/solver"
+)
+
+// poolNewRows is how many blank rows /pool offers for new sessions.
+const poolNewRows = 5
+
+// Proposal is one solver placement shown for review.
+type Proposal struct {
+	Session Session
+	Cell    string // "{cid}_{blockID}"
+	Room    string
+	Seats   int
+	When    string
+}
+
+// Left is a pool session the solver couldn't place.
+type Left struct {
+	Session Session
+	Reason  string
+}
+
+// Session pool – accepted sessions waiting for a room and block
+func PoolHandler(w http.ResponseWriter, r *http.Request) {
+	mu.RLock()
+	pool := append([]Session(nil), sessionsCache[0]...)
+	rooms := sortedClassrooms(classroomsCache)
+	tracks := tracksCache
+	slots := 0
+	for _, b := range blocksCache {
+		if !b.Spanning() {
+			slots++
+		}
+	}
+	mu.RUnlock()
+
+	var newRows []int
+	for i := 0; i < poolNewRows; i++ {
+		newRows = append(newRows, len(pool)+i)
+	}
+
+	data := struct {
+		Pool    []Session
+		NewRows []int
+		Rooms   []*Classroom
+		Tracks  []*Track
+		Slots   int
+
+		Active    string
+		PageTitle string
+		Year      int
+		ExtraCSS  []string
+		Flash     string
+	}{
+		Pool:    pool,
+		NewRows: newRows,
+		Rooms:   rooms,
+		Tracks:  tracks,
+		Slots:   slots,
+
+		Active:    "pool",
+		PageTitle: "Session Pool",
+		Year:      eventNow().Year(),
+		ExtraCSS:  []string{"pool.css"},
+		Flash:     r.URL.Query().Get("saved"),
+	}
+	RenderTemplate(w, "pool.html", data)
+}
+
+// Save the pool table. Rows are numbered 0..n; a row with an id edits that
+// unscheduled session, a row without one adds a new session.
+func PoolSaveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	r.ParseForm()
+
+	mu.Lock()
+	byID := make(map[int]Session)
+	for _, s := range sessionsCache[0] {
+		byID[s.ID] = s
+	}
+	var pool []Session
+	for i := 0; r.Form.Has("title_" + strconv.Itoa(i)); i++ {
+		n := strconv.Itoa(i)
+		id, _ := strconv.Atoi(r.FormValue("id_" + n))
+		s, existing := byID[id]
+		if r.FormValue("remove_"+n) != "" {
+			continue
+		}
+		s.Title = strings.TrimSpace(r.FormValue("title_" + n))
+		if len(s.PresenterIDs) == 0 {
+			s.Presenter = strings.TrimSpace(r.FormValue("presenter_" + n))
+		}
+		s.TrackID = formTrack(r, "track_"+n)
+		s.Tags = parseTags(r.FormValue("tags_" + n))
+		s.Audience = formInt(r, "audience_"+n)
+		s.Length = formInt(r, "length_"+n)
+		if !existing && s.isEmpty() {
+			continue
+		}
+		pool = append(pool, s)
+	}
+	err := editSessions(r.Context(), func(sessions map[int][]Session) error {
+		sessions[0] = pool
+		syncRepeats(sessions)
+		return nil
+	})
+	mu.Unlock()
+	if err != nil {
+		http.Redirect(w, r, "/pool?saved="+url.QueryEscape(saveFailed(err)), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/pool?saved=Session+pool+saved", http.StatusSeeOther)
+}
+
+// Run the solver and show its proposal – nothing is saved here.
+func PoolSolveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+
+	mu.RLock()
+	res := solver.Solve(buildProblem())
+	pool := make(map[int]Session)
+	for _, s := range sessionsCache[0] {
+		pool[s.ID] = s
+	}
+	var proposals []Proposal
+	for _, a := range res.Assignments {
+		b := findBlock(a.SlotID)
+		c := classroomsCache[a.RoomID]
+		proposals = append(proposals, Proposal{
+			Session: pool[a.ItemID],
+			Cell:    strconv.Itoa(a.RoomID) + "_" + strconv.Itoa(a.SlotID),
+			Room:    c.Name,
+			Seats:   c.Capacity,
+			When:    timeRange(b.Day, b.StartTime, b.EndTime),
+		})
+	}
+	var left []Left
+	for _, u := range res.Unplaced {
+		left = append(left, Left{pool[u.ItemID], u.Reason})
+	}
+	mu.RUnlock()
+
+	data := struct {
+		Proposals    []Proposal
+		Left         []Left
+		TrackClashes int
+
+		Active    string
+		PageTitle string
+		Year      int
+		ExtraCSS  []string
+		Flash     string
+	}{
+		Proposals:    proposals,
+		Left:         left,
+		TrackClashes: res.TrackClashes,
+
+		Active:    "pool",
+		PageTitle: "Proposed Schedule",
+		Year:      eventNow().Year(),
+		ExtraCSS:  []string{"pool.css"},
+		Flash:     "",
+	}
+	RenderTemplate(w, "pool_review.html", data)
+}
+
+// Apply the placements the admin kept ticked. Each one is checked again in
+// case the schedule changed since the proposal was made.
+func PoolApplyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	r.ParseForm()
+
+	mu.Lock()
+	placed, skipped := 0, 0
+	err := editSessions(r.Context(), func(sessions map[int][]Session) error {
+		for _, v := range r.Form["accept"] {
+			idStr, cellKey, _ := strings.Cut(v, ":")
+			id, _ := strconv.Atoi(idStr)
+			cid, blockID, ok := parseCell(cellKey)
+			b := findBlock(blockID)
+			idx := -1
+			for i, s := range sessions[0] {
+				if s.ID == id {
+					idx = i
+				}
+			}
+			if !ok || idx < 0 || b == nil || b.Spanning() || classroomsCache[cid] == nil || sessionCovering(sessions, cid, blockID) != nil {
+				skipped++
+				continue
+			}
+			s := sessions[0][idx]
+			sessions[0] = append(sessions[0][:idx], sessions[0][idx+1:]...)
+			placeSession(sessions, s, cid, b)
+			placed++
+		}
+		return nil
+	})
+	conflicts := analyzeConflicts()
+	mu.Unlock()
+	if err != nil {
+		http.Redirect(w, r, "/pool?saved="+url.QueryEscape(saveFailed(err)), http.StatusSeeOther)
+		return
+	}
+
+	log.Printf("Solver proposal applied: %d placed, %d skipped", placed, skipped)
+	msg := strconv.Itoa(placed) + " session(s) placed"
+	if skipped > 0 {
+		msg += ", " + strconv.Itoa(skipped) + " skipped because their slot was taken meanwhile"
+	}
+	if len(conflicts) > 0 {
+		msg += " – " + strconv.Itoa(len(conflicts)) + " conflict(s) need a look"
+	}
+	http.Redirect(w, r, "/config?saved="+url.QueryEscape(msg), http.StatusSeeOther)
+}
+
+// buildProblem describes the current event to the solver – caller holds mu.
+func buildProblem() solver.Problem {
+	var p solver.Problem
+	for _, c := range sortedClassrooms(classroomsCache) {
+		p.Rooms = append(p.Rooms, solver.Room{ID: c.ID, Capacity: c.Capacity})
+	}
+	for _, b := range blocksCache {
+		if !b.Spanning() {
+			p.Slots = append(p.Slots, solver.Slot{ID: b.ID, Day: b.Day, Start: b.StartTime, End: b.EndTime})
+		}
+	}
+	for cid, list := range sessionsCache {
+		for _, s := range list {
+			if cid == 0 && s.Span > 1 {
+				continue // the solver fills single blocks – these go on /grid
+			}
+			if cid == 0 {
+				p.Items = append(p.Items, solver.Item{
+					ID:       s.ID,
+					People:   sessionPeople(s),
+					Audience: s.Audience,
+					Track:    solverTrack(s.TrackID),
+					Minutes:  s.Length,
+				})
+				continue
+			}
+			if classroomsCache[cid] == nil {
+				continue
+			}
+			// A multi-block session books each of its blocks, split at the
+			// block edges so it isn't counted twice for the same time
+			run := sessionBlocks(s)
+			for i, b := range run {
+				start, end := b.StartTime, b.EndTime
+				if i == 0 {
+					start = s.StartTime
+				}
+				if i == len(run)-1 {
+					end = s.EndTime
+				}
+				p.Booked = append(p.Booked, solver.Booking{
+					RoomID: cid,
+					SlotID: b.ID,
+					Day:    s.Day,
+					Start:  start,
+					End:    end,
+					People: sessionPeople(s),
+					Track:  solverTrack(s.TrackID),
+				})
+			}
+		}
+	}
+	return p
+}
+
+// solverTrack is how the solver tells tracks apart.
+func solverTrack(id int) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
+
+func formInt(r *http.Request, name string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(r.FormValue(name)))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,253 @@
+package web
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// postgresMigrations are the migrations of the PostgreSQL store, kept like
+// those of scheduler.db: append only, recorded in schema_version. Step 1 is
+// the schema from before versioning, so it checks before it creates.
+var postgresMigrations = []migration{
+	{Version: 1, Name: "Create tables", SQL: `
+	CREATE TABLE IF NOT EXISTS events (
+		id SERIAL PRIMARY KEY,
+		name TEXT NOT NULL DEFAULT '',
+		location TEXT NOT NULL DEFAULT '',
+		start_date TEXT NOT NULL DEFAULT '',
+		end_date TEXT NOT NULL DEFAULT '',
+		time_zone TEXT NOT NULL DEFAULT '',
+		time_format TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE IF NOT EXISTS classrooms (
+		event_id INTEGER NOT NULL,
+		id INTEGER NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		capacity INTEGER NOT NULL DEFAULT 0,
+		building TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY(event_id, id)
+	);
+	CREATE TABLE IF NOT EXISTS blocks (
+		event_id INTEGER NOT NULL,
+		id INTEGER NOT NULL,
+		day TEXT NOT NULL DEFAULT '',  -- YYYY-MM-DD
+		start_time TEXT NOT NULL,
+		end_time TEXT NOT NULL,
+		kind TEXT NOT NULL DEFAULT 'session',
+		title TEXT NOT NULL DEFAULT '',
+		location TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY(event_id, id)
+	);
+	CREATE TABLE IF NOT EXISTS sessions (
+		id SERIAL PRIMARY KEY,
+		event_id INTEGER NOT NULL,
+		classroom_id INTEGER,
+		block_id INTEGER NOT NULL DEFAULT 0,  -- 0 = unscheduled
+		day TEXT NOT NULL DEFAULT '',
+		start_time TEXT NOT NULL,
+		end_time TEXT NOT NULL,
+		title TEXT NOT NULL,
+		presenter TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		audience INTEGER NOT NULL DEFAULT 0,
+		track_id INTEGER NOT NULL DEFAULT 0,
+		tags TEXT NOT NULL DEFAULT '',
+		level TEXT NOT NULL DEFAULT '',
+		programs TEXT NOT NULL DEFAULT '',
+		length_minutes INTEGER NOT NULL DEFAULT 0,
+		block_span INTEGER NOT NULL DEFAULT 1,
+		series_id INTEGER NOT NULL DEFAULT 0,
+		series_part INTEGER NOT NULL DEFAULT 0,
+		repeat_of INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS sessions_event ON sessions (event_id);
+	CREATE TABLE IF NOT EXISTS presenters (
+		id SERIAL PRIMARY KEY,
+		event_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		team_number TEXT NOT NULL DEFAULT '',
+		email TEXT NOT NULL DEFAULT '',
+		bio TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE IF NOT EXISTS session_presenters (
+		session_id INTEGER NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+		presenter_id INTEGER NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY(session_id, presenter_id)
+	);
+	CREATE TABLE IF NOT EXISTS tracks (
+		id SERIAL PRIMARY KEY,
+		event_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		color TEXT NOT NULL DEFAULT '#0066cc',
+		position INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE IF NOT EXISTS series (
+		id SERIAL PRIMARY KEY,
+		event_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		same_room INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE IF NOT EXISTS publications (
+		id SERIAL PRIMARY KEY,
+		event_id INTEGER NOT NULL,
+		at TEXT NOT NULL DEFAULT '',
+		"user" TEXT NOT NULL DEFAULT '',
+		retired INTEGER NOT NULL DEFAULT 0,
+		data TEXT NOT NULL DEFAULT '{}'
+	);
+	CREATE TABLE IF NOT EXISTS schedule_templates (
+		id SERIAL PRIMARY KEY,
+		name TEXT NOT NULL DEFAULT '',
+		created TEXT NOT NULL DEFAULT '',
+		with_sessions INTEGER NOT NULL DEFAULT 0,
+		data TEXT NOT NULL DEFAULT '{}'
+	);
+	CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT
+	);
+	CREATE TABLE IF NOT EXISTS event_settings (
+		event_id INTEGER NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT,
+		PRIMARY KEY(event_id, key)
+	);
+	CREATE TABLE IF NOT EXISTS schedule_revisions (
+		event_id INTEGER PRIMARY KEY,
+		revision INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE IF NOT EXISTS change_batches (
+		id SERIAL PRIMARY KEY,
+		event_id INTEGER NOT NULL,
+		at TEXT NOT NULL DEFAULT '',
+		"user" TEXT NOT NULL DEFAULT '',
+		action TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE IF NOT EXISTS changes (
+		id SERIAL PRIMARY KEY,
+		batch_id INTEGER NOT NULL REFERENCES change_batches(id) ON DELETE CASCADE,
+		event_id INTEGER NOT NULL,
+		entity TEXT NOT NULL,
+		entity_id INTEGER NOT NULL,
+		label TEXT NOT NULL DEFAULT '',
+		field TEXT NOT NULL DEFAULT '',
+		old_value TEXT NOT NULL DEFAULT '',
+		new_value TEXT NOT NULL DEFAULT '',
+		reverted INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS changes_event ON changes (event_id, batch_id);`},
+}
+
+// postgresMigrationLock is the advisory lock that has instances starting
+// together take turns at the migrations.
+const postgresMigrationLock = 4607
+
+// OpenPostgresStore connects to the PostgreSQL database at url and brings
+// its tables up to date. The database starts out empty; nothing is copied
+// over from scheduler.db.
+func OpenPostgresStore(url string) (Store, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect to PostgreSQL: %w", err)
+	}
+	if err := migratePostgres(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("upgrade PostgreSQL tables: %w", err)
+	}
+	return &sqlStore{
+		db:      db,
+		bind:    bindDollar,
+		syncIDs: syncSerial,
+	}, nil
+}
+
+// migratePostgres applies the postgresMigrations db has not had yet. Each
+// step runs in its own transaction under postgresMigrationLock and is
+// skipped if another instance applied it while this one waited.
+func migratePostgres(db *sql.DB) error {
+	latest := postgresMigrations[len(postgresMigrations)-1].Version
+	var current int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&current); err == nil && current > latest {
+		return fmt.Errorf("the database is at schema version %d, but this build only knows up to %d – run a newer build", current, latest)
+	}
+	for _, m := range postgresMigrations {
+		if m.Version <= current {
+			continue
+		}
+		applied, err := applyPostgresMigration(db, m)
+		if err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		if applied {
+			log.Printf("Applied PostgreSQL migration %d: %s", m.Version, m.Name)
+		}
+	}
+	return nil
+}
+
+func applyPostgresMigration(db *sql.DB, m migration) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() // no-op once committed
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", postgresMigrationLock); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(schemaVersionSQL); err != nil {
+		return false, err
+	}
+	var done bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM schema_version WHERE version = $1)", m.Version).Scan(&done); err != nil || done {
+		return false, err
+	}
+	if m.SQL != "" {
+		if _, err := tx.Exec(m.SQL); err != nil {
+			return false, err
+		}
+	}
+	if m.Up != nil {
+		if err := m.Up(tx); err != nil {
+			return false, err
+		}
+	}
+	if _, err := tx.Exec("INSERT INTO schema_version (version, name, applied_at) VALUES ($1, $2, $3)",
+		m.Version, m.Name, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// syncSerial moves the SERIAL counter of table past the IDs written into it
+// by hand, so the next new row does not take one of them.
+func syncSerial(table string) string {
+	return "SELECT setval(pg_get_serial_sequence('" + table + "', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM " + table
+}
+
+// bindDollar numbers the ? placeholders of a query the way PostgreSQL wants
+// them: "a = ? AND b = ?" → "a = $1 AND b = $2".
+func bindDollar(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,324 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Presenters page – list, create and edit presenters
+func PresentersHandler(w http.ResponseWriter, r *http.Request) {
+	mu.RLock()
+	type row struct {
+		*Presenter
+		Sessions int
+	}
+	var list []row
+	for _, p := range presentersCache {
+		list = append(list, row{p, len(presenterSessions(p.ID))})
+	}
+	mu.RUnlock()
+
+	data := struct {
+		Presenters []row
+
+		Active    string
+		PageTitle string
+		Year      int
+		ExtraCSS  []string
+		Flash     string
+	}{
+		Presenters: list,
+
+		Active:    "presenters",
+		PageTitle: "Presenters",
+		Year:      eventNow().Year(),
+		ExtraCSS:  []string{"presenters.css"},
+		Flash:     r.URL.Query().Get("saved"),
+	}
+	RenderTemplate(w, "presenters.html", data)
+}
+
+// Presenter detail page – every session across rooms and blocks
+func PresenterHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Path[len("/presenter/"):])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	mu.RLock()
+	sc := publicSchedule(r)
+	p := findPresenter(id)
+	var pres Presenter
+	if p != nil {
+		pres = *p
+	}
+	type row struct {
+		Session
+		Room string
+	}
+	var sessions []row
+	for _, s := range sc.presenterSessions(id) {
+		room := "Unscheduled"
+		if c, ok := sc.Classrooms[s.ClassroomID]; ok {
+			room = c.Name
+		}
+		sessions = append(sessions, row{s, room})
+	}
+	mu.RUnlock()
+
+	if p == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	data := struct {
+		Presenter Presenter
+		Sessions  []row
+
+		Active    string
+		PageTitle string
+		Year      int
+		ExtraCSS  []string
+		Flash     string
+	}{
+		Presenter: pres,
+		Sessions:  sessions,
+
+		Active:    "presenters",
+		PageTitle: pres.Name,
+		Year:      eventNow().Year(),
+		ExtraCSS:  []string{"presenters.css"},
+		Flash:     r.URL.Query().Get("saved"),
+	}
+	RenderTemplate(w, "presenter.html", data)
+}
+
+// Create (id empty) or update a presenter
+func PresentersSaveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	r.ParseForm()
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		http.Redirect(w, r, "/presenters?saved=Presenter+name+is+required", http.StatusSeeOther)
+		return
+	}
+
+	mu.Lock()
+	id, _ := strconv.Atoi(r.FormValue("id"))
+	var p *Presenter
+	err := editSessionLists(r.Context(), func(sc *Schedule) error {
+		if p = sc.findPresenter(id); p == nil {
+			p = &Presenter{ID: sc.newID()}
+			sc.Presenters = append(sc.Presenters, p)
+		}
+		p.Name = name
+		p.TeamNumber = strings.TrimSpace(r.FormValue("team_number"))
+		p.Email = strings.TrimSpace(r.FormValue("email"))
+		p.Bio = strings.TrimSpace(r.FormValue("bio"))
+		sc.syncPresenterNames()
+		return nil
+	})
+	mu.Unlock()
+	if err != nil {
+		http.Redirect(w, r, "/presenters?saved="+url.QueryEscape(saveFailed(err)), http.StatusSeeOther)
+		return
+	}
+	log.Printf("Saved presenter #%d %q", p.ID, p.Name)
+
+	http.Redirect(w, r, "/presenters?saved=Presenter+saved", http.StatusSeeOther)
+}
+
+func PresenterDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	id, _ := strconv.Atoi(r.FormValue("id"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if findPresenter(id) == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Unlinked from every session in the same save
+	err := editSessionLists(r.Context(), func(sc *Schedule) error {
+		kept := sc.Presenters[:0]
+		for _, p := range sc.Presenters {
+			if p.ID != id {
+				kept = append(kept, p)
+			}
+		}
+		sc.Presenters = kept
+		for _, list := range sc.Sessions {
+			for i := range list {
+				var ids []int
+				for _, pid := range list[i].PresenterIDs {
+					if pid != id {
+						ids = append(ids, pid)
+					}
+				}
+				list[i].PresenterIDs = ids
+			}
+		}
+		sc.syncPresenterNames()
+		return nil
+	})
+	if err != nil {
+		http.Redirect(w, r, "/presenters?saved="+url.QueryEscape(saveFailed(err)), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/presenters?saved=Presenter+deleted", http.StatusSeeOther)
+}
+
+// Create presenters from the free-text presenter field of every session and
+// link them, splitting "Team 4607, Jane Doe & 2052" the same way the CSV
+// importer does.
+func PresentersImportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+
+	mu.Lock()
+	created, linked := 0, 0
+	err := editSessionLists(r.Context(), func(sc *Schedule) error {
+		byName := make(map[string]*Presenter)
+		for _, p := range sc.Presenters {
+			byName[strings.ToLower(p.Name)] = p
+		}
+		for _, list := range sc.Sessions {
+			for i := range list {
+				s := &list[i]
+				if len(s.PresenterIDs) > 0 || s.Presenter == "" {
+					continue
+				}
+				for _, name := range splitPresenterNames(s.Presenter) {
+					p := byName[strings.ToLower(name)]
+					if p == nil {
+						p = &Presenter{ID: sc.newID(), Name: name}
+						if team, ok := strings.CutPrefix(name, "Team "); ok {
+							p.TeamNumber = team
+						}
+						sc.Presenters = append(sc.Presenters, p)
+						byName[strings.ToLower(name)] = p
+						created++
+					}
+					s.PresenterIDs = append(s.PresenterIDs, p.ID)
+				}
+				if len(s.PresenterIDs) > 0 {
+					linked++
+				}
+			}
+		}
+		sc.syncPresenterNames()
+		return nil
+	})
+	mu.Unlock()
+	if err != nil {
+		http.Redirect(w, r, "/presenters?saved="+url.QueryEscape(saveFailed(err)), http.StatusSeeOther)
+		return
+	}
+
+	log.Printf("Presenter import: %d created, %d sessions linked", created, linked)
+	msg := strconv.Itoa(created) + "+presenters+created,+" + strconv.Itoa(linked) + "+sessions+linked"
+	http.Redirect(w, r, "/presenters?saved="+msg, http.StatusSeeOther)
+}
+
+var (
+	presenterSplit = regexp.MustCompile(`[,&/]`)
+	teamNumber     = regexp.MustCompile(`^\d{1,5}$`)
+)
+
+// splitPresenterNames turns "4607, Jane Doe & 2052" into
+// ["Team 4607", "Jane Doe", "Team 2052"].
+func splitPresenterNames(raw string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, part := range presenterSplit.Split(raw, -1) {
+		part = strings.TrimSpace(part)
+		if part == "" || strings.EqualFold(part, "tbd") {
+			continue
+		}
+		if teamNumber.MatchString(part) {
+			part = "Team " + part
+		}
+		if !seen[strings.ToLower(part)] {
+			seen[strings.ToLower(part)] = true
+			names = append(names, part)
+		}
+	}
+	return names
+}
+
+// findPresenter – caller holds mu.
+func findPresenter(id int) *Presenter {
+	return draftSchedule().findPresenter(id)
+}
+
+func (sc Schedule) findPresenter(id int) *Presenter {
+	for _, p := range sc.Presenters {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// presenterNames joins linked presenters for display – caller holds mu.
+func presenterNames(ids []int) string {
+	return draftSchedule().presenterNames(ids)
+}
+
+func (sc Schedule) presenterNames(ids []int) string {
+	var names []string
+	for _, id := range ids {
+		if p := sc.findPresenter(id); p != nil {
+			names = append(names, p.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// syncPresenterNames refreshes the display text of every session with linked
+// presenters after a presenter was added, renamed or removed.
+func (sc *Schedule) syncPresenterNames() {
+	for _, list := range sc.Sessions {
+		for i := range list {
+			if len(list[i].PresenterIDs) > 0 {
+				list[i].Presenter = sc.presenterNames(list[i].PresenterIDs)
+			}
+		}
+	}
+}
+
+// presenterSessions lists a presenter's sessions by day and time – caller holds mu.
+func presenterSessions(id int) []Session {
+	return draftSchedule().presenterSessions(id)
+}
+
+func (sc Schedule) presenterSessions(id int) []Session {
+	var out []Session
+	for _, list := range sc.Sessions {
+		for _, s := range list {
+			for _, pid := range s.PresenterIDs {
+				if pid == id {
+					out = append(out, s)
+					break
+				}
+			}
+		}
+	}
+	sortSessions(out)
+	return out
+}
@@ -0,0 +1,324 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Publication is a copy of the rooms, blocks and sessions that the public
+// pages (/, /classroom/{id}, /presenter/{id}) show. Everything else in the
+// app edits the draft – the caches – until an admin publishes it.
+type Publication struct {
+	ID      int
+	EventID int
+	At      string // RFC 3339, UTC
+	User    string
+	Retired bool // rolled back – never shown again
+
+	Classrooms map[int]Classroom
+	Blocks     map[int]Block
+	Sessions   map[int]Session // ClassroomID 0 = unscheduled
+
+	When string // At in the event's zone and format – display only
+}
+
+// publicationLimit is how many earlier versions /publish lists.
+const publicationLimit = 20
+
+// publishedCache is what attendees see for the current event; nil only
+// while the caches load. publishedSchedule is it laid out like the caches,
+// for the public pages – only ever read.
+var (
+	publishedCache    *Publication
+	publishedSchedule Schedule
+)
+
+// Publish page – what publishing would change, and the versions so far
+func PublishHandler(w http.ResponseWriter, r *http.Request) {
+	mu.RLock()
+	var changes []Change
+	if publishedCache != nil {
+		changes = draftChanges(publishedCache)
+		for i := range changes {
+			describeChange(&changes[i])
+		}
+	}
+	conflicts := len(analyzeConflicts())
+	versions := loadPublicationsFromDB(currentEventID, publicationLimit)
+	for i := range versions {
+		versions[i].When = displayTime(versions[i].At)
+	}
+	current := 0
+	if publishedCache != nil {
+		current = publishedCache.ID
+	}
+	mu.RUnlock()
+
+	canRollback := false
+	for _, v := range versions {
+		if !v.Retired && v.ID < current {
+			canRollback = true
+			break
+		}
+	}
+
+	data := struct {
+		Changes     []Change
+		Conflicts   int
+		Versions    []Publication
+		Current     int
+		CanRollback bool
+
+		Active    string
+		PageTitle string
+		Year      int
+		ExtraCSS  []string
+		Flash     string
+	}{
+		Changes:     changes,
+		Conflicts:   conflicts,
+		Versions:    versions,
+		Current:     current,
+		CanRollback: canRollback,
+
+		Active:    "publish",
+		PageTitle: "Publish",
+		Year:      eventNow().Year(),
+		ExtraCSS:  []string{"history.css", "publish.css"},
+		Flash:     r.URL.Query().Get("saved"),
+	}
+	RenderTemplate(w, "publish.html", data)
+}
+
+// Make the draft what attendees see
+func PublishSaveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if publishedCache != nil && len(draftChanges(publishedCache)) == 0 {
+		http.Redirect(w, r, "/publish?saved=Nothing+to+publish+–+the+draft+matches+the+published+schedule", http.StatusSeeOther)
+		return
+	}
+	p := draftPublication(editorName(r))
+	if err := savePublicationToDB(p); err != nil {
+		http.Redirect(w, r, "/publish?saved="+url.QueryEscape(publishFailed("Nothing was published", err)), http.StatusSeeOther)
+		return
+	}
+	setPublished(p)
+
+	log.Printf("Published version #%d of event #%d", p.ID, currentEventID)
+	http.Redirect(w, r, "/publish?saved=Published+–+attendees+now+see+this+version", http.StatusSeeOther)
+}
+
+// Go back to the version published before the current one. The draft is
+// left as it is.
+func PublishRollbackHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if publishedCache == nil {
+		http.Redirect(w, r, "/publish", http.StatusSeeOther)
+		return
+	}
+	prev, err := loadPublishedFromDB(currentEventID, publishedCache.ID)
+	if err != nil {
+		http.Redirect(w, r, "/publish?saved="+url.QueryEscape("Could not read the earlier versions: "+err.Error()), http.StatusSeeOther)
+		return
+	}
+	if prev == nil {
+		http.Redirect(w, r, "/publish?saved=There+is+no+earlier+version+to+go+back+to", http.StatusSeeOther)
+		return
+	}
+	if err := retirePublicationInDB(publishedCache.ID); err != nil {
+		http.Redirect(w, r, "/publish?saved="+url.QueryEscape(publishFailed("Nothing was rolled back", err)), http.StatusSeeOther)
+		return
+	}
+	setPublished(prev)
+
+	log.Printf("Rolled event #%d back to published version #%d", currentEventID, prev.ID)
+	http.Redirect(w, r, "/publish?saved="+url.QueryEscape("Rolled back – attendees see version #"+strconv.Itoa(prev.ID)+" again"), http.StatusSeeOther)
+}
+
+// Throw the draft away and start again from the published version
+func PublishDiscardHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if publishedCache == nil {
+		http.Redirect(w, r, "/publish", http.StatusSeeOther)
+		return
+	}
+	rooms, sessions, blocks := publishedCache.caches()
+	if err := saveSchedule(r.Context(), Schedule{Classrooms: rooms, Blocks: blocks, Sessions: sessions}); err != nil {
+		http.Redirect(w, r, "/publish?saved="+url.QueryEscape(saveFailed(err)), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/publish?saved=Draft+reset+to+the+published+schedule", http.StatusSeeOther)
+}
+
+// draftSchedule is the schedule being edited – caller holds mu.
+func draftSchedule() Schedule {
+	return Schedule{Classrooms: classroomsCache, Blocks: blocksCache, Sessions: sessionsCache,
+		Presenters: presentersCache, Tracks: tracksCache, Series: seriesCache}
+}
+
+// publicSchedule is what a public page shows: the published version, or the
+// draft when the page was asked for ?draft=1. Pages only read it, so the
+// read lock is enough – caller holds mu.
+func publicSchedule(r *http.Request) Schedule {
+	if r.URL.Query().Get("draft") != "" || publishedCache == nil {
+		return draftSchedule()
+	}
+	return publishedSchedule
+}
+
+// setPublished makes p what attendees see – caller holds mu.
+func setPublished(p *Publication) {
+	publishedCache = p
+	rooms, sessions, blocks := p.caches()
+	publishedSchedule = Schedule{Classrooms: rooms, Blocks: blocks, Sessions: sessions}
+}
+
+// loadPublished picks up the current event's published version. An event
+// that has never been published publishes what it has, so attendees keep
+// seeing what they saw before. If that cannot be read or saved, nothing
+// counts as published and the public pages show the draft – caller holds mu.
+func loadPublished() {
+	publishedCache, publishedSchedule = nil, Schedule{}
+	p, err := loadPublishedFromDB(currentEventID, 0)
+	if err != nil {
+		return
+	}
+	if p == nil {
+		// Straight to the store: a stale save would reload the caches,
+		// and with them this
+		p = draftPublication("")
+		rev, err := store.SavePublication(p, scheduleRevision)
+		if err != nil {
+			log.Println("Failed to save publication:", err)
+			return
+		}
+		scheduleRevision = rev
+	}
+	setPublished(p)
+}
+
+// publishFailed is saveFailed for a publish or rollback that did not happen.
+func publishFailed(nothing string, err error) string {
+	if errors.Is(err, ErrStale) {
+		return saveFailed(err)
+	}
+	return nothing + " – the database refused the change: " + err.Error()
+}
+
+// draftPublication copies the draft as a new version – caller holds mu.
+func draftPublication(user string) *Publication {
+	return &Publication{
+		EventID:    currentEventID,
+		At:         time.Now().UTC().Format(time.RFC3339),
+		User:       user,
+		Classrooms: currentClassrooms(),
+		Blocks:     currentBlocks(),
+		Sessions:   currentSessions(),
+	}
+}
+
+// draftChanges lists how the draft differs from p – caller holds mu.
+func draftChanges(p *Publication) []Change {
+	var changes []Change
+	changes = append(changes, diffRecords(EntityClassroom, p.Classrooms, currentClassrooms(), classroomFields, classroomLabel)...)
+	changes = append(changes, diffRecords(EntityBlock, p.Blocks, currentBlocks(), blockFields, blockLabel)...)
+	changes = append(changes, diffRecords(EntitySession, p.Sessions, currentSessions(), sessionFields, sessionLabel)...)
+	return changes
+}
+
+// caches builds fresh cache values from a publication, so nothing a page
+// does to them reaches the stored copy.
+func (p *Publication) caches() (map[int]*Classroom, map[int][]Session, []Block) {
+	rooms := make(map[int]*Classroom, len(p.Classrooms))
+	for id, c := range p.Classrooms {
+		c := c
+		rooms[id] = &c
+	}
+	sessions := make(map[int][]Session)
+	for _, id := range sortedKeys(p.Sessions) {
+		s := p.Sessions[id]
+		s.Tags = append([]string(nil), s.Tags...)
+		s.Programs = append([]string(nil), s.Programs...)
+		s.PresenterIDs = append([]int(nil), s.PresenterIDs...)
+		sessions[s.ClassroomID] = append(sessions[s.ClassroomID], s)
+	}
+	blocks := make([]Block, 0, len(p.Blocks))
+	for _, b := range p.Blocks {
+		blocks = append(blocks, b)
+	}
+	sortBlocks(blocks)
+	return rooms, sessions, blocks
+}
+
+// publishedData is how a publication's schedule is stored.
+type publishedData struct {
+	Classrooms []Classroom
+	Blocks     []Block
+	Sessions   []Session
+}
+
+func encodePublication(p *Publication) string {
+	var d publishedData
+	for _, id := range sortedKeys(p.Classrooms) {
+		d.Classrooms = append(d.Classrooms, p.Classrooms[id])
+	}
+	for _, id := range sortedKeys(p.Blocks) {
+		d.Blocks = append(d.Blocks, p.Blocks[id])
+	}
+	for _, id := range sortedKeys(p.Sessions) {
+		d.Sessions = append(d.Sessions, p.Sessions[id])
+	}
+	data, _ := json.Marshal(d)
+	return string(data)
+}
+
+func decodePublication(p *Publication, data string) {
+	var d publishedData
+	if err := json.Unmarshal([]byte(data), &d); err != nil {
+		log.Printf("Published version #%d is unreadable: %v", p.ID, err)
+	}
+	p.Classrooms = make(map[int]Classroom, len(d.Classrooms))
+	for _, c := range d.Classrooms {
+		p.Classrooms[c.ID] = c
+	}
+	p.Blocks = make(map[int]Block, len(d.Blocks))
+	for _, b := range d.Blocks {
+		p.Blocks[b.ID] = b
+	}
+	p.Sessions = make(map[int]Session, len(d.Sessions))
+	for _, s := range d.Sessions {
+		p.Sessions[s.ID] = s
+	}
+}
+
+// displayTime shows a stored RFC 3339 time in the event's zone and format –
+// caller holds mu.
+func displayTime(at string) string {
+	t, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		return at
+	}
+	local := t.In(eventNow().Location())
+	return local.Format("Mon Jan 2") + " " + displayClock(local.Format("15:04"))
+}
@@ -0,0 +1,94 @@
+package web
+
+import "sort"
+
+// copyContent gives a repeat round its original's title, presenters and
+// everything else attendees see – rooms and times stay its own.
+func copyContent(dst *Session, src Session) {
+	dst.Title = src.Title
+	dst.Presenter = src.Presenter
+	dst.PresenterIDs = append([]int(nil), src.PresenterIDs...)
+	dst.Description = src.Description
+	dst.Audience = src.Audience
+	dst.TrackID = src.TrackID
+	dst.Tags = append([]string(nil), src.Tags...)
+	dst.Level = src.Level
+	dst.Programs = append([]string(nil), src.Programs...)
+	dst.Length = src.Length
+}
+
+// syncRepeats copies every original session's content onto its repeat
+// rounds. A round whose original is gone becomes the original for the rest.
+func syncRepeats(sessions map[int][]Session) {
+	byID := make(map[int]*Session)
+	for _, list := range sessions {
+		for i := range list {
+			if list[i].ID != 0 {
+				byID[list[i].ID] = &list[i]
+			}
+		}
+	}
+
+	// Promote the lowest-ID round of each missing original
+	orphans := make(map[int][]*Session)
+	for _, s := range byID {
+		if s.RepeatOf != 0 && byID[s.RepeatOf] == nil {
+			orphans[s.RepeatOf] = append(orphans[s.RepeatOf], s)
+		}
+	}
+	for _, rounds := range orphans {
+		sort.Slice(rounds, func(i, j int) bool { return rounds[i].ID < rounds[j].ID })
+		rounds[0].RepeatOf = 0
+		for _, s := range rounds[1:] {
+			s.RepeatOf = rounds[0].ID
+		}
+	}
+
+	for _, list := range sessions {
+		for i := range list {
+			if orig := byID[list[i].RepeatOf]; list[i].RepeatOf != 0 && orig != nil {
+				copyContent(&list[i], *orig)
+			}
+		}
+	}
+}
+
+// repeatRoot is the ID of the original session of s's group.
+func repeatRoot(s Session) int {
+	if s.RepeatOf != 0 {
+		return s.RepeatOf
+	}
+	return s.ID
+}
+
+// markRepeats fills AlsoAt on copies of sessions for display: when and where
+// the other rounds of a repeated session run – caller holds mu.
+func (sc Schedule) markRepeats(sessions []Session) {
+	for i := range sessions {
+		s := &sessions[i]
+		if s.Spanning != nil || s.ID == 0 {
+			continue
+		}
+		root := repeatRoot(*s)
+		var others []Session
+		for cid, list := range sc.Sessions {
+			if cid == 0 {
+				continue
+			}
+			for _, o := range list {
+				if o.ID != s.ID && repeatRoot(o) == root {
+					others = append(others, o)
+				}
+			}
+		}
+		sortSessions(others)
+		s.AlsoAt = nil
+		for _, o := range others {
+			when := displayClock(o.StartTime)
+			if o.Day != s.Day && o.Day != "" {
+				when = dayLabel(o.Day) + " " + when
+			}
+			s.AlsoAt = append(s.AlsoAt, when+" in "+sc.roomName(o.ClassroomID))
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ScheduleTemplate is a saved copy of an event's rooms, blocks and settings –
+// and optionally its sessions – to start next year's schedule from.
+type ScheduleTemplate struct {
+	ID           int
+	Name         string
+	Created      string // YYYY-MM-DD
+	WithSessions bool
+	Snapshot     Snapshot
+}
+
+// Snapshot is everything a template holds. Days are stored as positions
+// (0 = first day) so a template fits an event on any dates.
+type Snapshot struct {
+	Event         Event // location, time zone and format only
+	Classrooms    []Classroom
+	Blocks        []SnapshotBlock
+	SessionLength int
+	BreakMinutes  int
+	Day           DayTemplate
+	Tracks        []Track
+	Presenters    []Presenter
+	Series        []Series
+	Sessions      []SnapshotSession
+}
+
+type SnapshotBlock struct {
+	Block
+	DayIndex int
+}
+
+type SnapshotSession struct {
+	Session
+	DayIndex int
+}
+
+// What a new event takes over from its template or source event.
+const (
+	CopyNoSessions = "none"
+	CopyToPool     = "pool"   // every session, unscheduled – titles and presenters kept
+	CopyPlaced     = "placed" // every session in its old room and block
+)
+
+// cloneOptions are the choices on the "start a new event" form.
+type cloneOptions struct {
+	Rooms    bool
+	Blocks   bool
+	Tracks   bool
+	Sessions string // CopyNoSessions, CopyToPool or CopyPlaced
+}
+
+// Templates page – save the current event as a template, or start a new
+// event from one
+func TemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	list := loadTemplatesFromDB()
+
+	data := struct {
+		Templates []*ScheduleTemplate
+		Today     string
+
+		Active    string
+		PageTitle string
+		Year      int
+		ExtraCSS  []string
+		Flash     string
+	}{
+		Templates: list,
+		Today:     eventNow().Format(dateLayout),
+
+		Active:    "templates",
+		PageTitle: "Schedule Templates",
+		Year:      eventNow().Year(),
+		ExtraCSS:  []string{"events.css"},
+		Flash:     r.URL.Query().Get("saved"),
+	}
+	RenderTemplate(w, "templates.html", data)
+}
+
+func TemplateSaveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		http.Redirect(w, r, "/templates?saved=Template+name+is+required", http.StatusSeeOther)
+		return
+	}
+
+	t := &ScheduleTemplate{
+		Name:         name,
+		Created:      eventNow().Format(dateLayout),
+		WithSessions: r.FormValue("with_sessions") != "",
+	}
+	mu.Lock()
+	t.Snapshot = takeSnapshot(t.WithSessions)
+	err := saveTemplateToDB(t)
+	mu.Unlock()
+	if err != nil {
+		http.Redirect(w, r, "/templates?saved="+url.QueryEscape(saveFailed(err)), http.StatusSeeOther)
+		return
+	}
+
+	log.Printf("Saved template #%d %q", t.ID, t.Name)
+	http.Redirect(w, r, "/templates?saved=Template+saved", http.StatusSeeOther)
+}
+
+func TemplateDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	id, _ := strconv.Atoi(r.FormValue("id"))
+	if err := deleteTemplateFromDB(id); err != nil {
+		http.Redirect(w, r, "/templates?saved="+url.QueryEscape(saveFailed(err)), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/templates?saved=Template+deleted", http.StatusSeeOther)
+}
+
+// Start a new event from a template ("source" is its ID) or as a copy of the
+// current event ("source" = "current"), then switch to it.
+func TemplateStartHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	r.ParseForm()
+
+	e := &Event{
+		Name:      strings.TrimSpace(r.FormValue("name")),
+		StartDate: strings.TrimSpace(r.FormValue("start_date")),
+		EndDate:   strings.TrimSpace(r.FormValue("end_date")),
+	}
+	if e.Name == "" {
+		http.Redirect(w, r, "/templates?saved=Event+name+is+required", http.StatusSeeOther)
+		return
+	}
+	if !validDate(e.StartDate) || !validDate(e.EndDate) || (e.StartDate != "" && e.EndDate != "" && e.EndDate < e.StartDate) {
+		http.Redirect(w, r, "/templates?saved=Check+the+start+and+end+dates", http.StatusSeeOther)
+		return
+	}
+	opts := cloneOptions{
+		Rooms:    r.FormValue("keep_rooms") != "",
+		Blocks:   r.FormValue("keep_blocks") != "",
+		Tracks:   r.FormValue("keep_tracks") != "",
+		Sessions: r.FormValue("sessions"),
+	}
+	if opts.Sessions != CopyToPool && opts.Sessions != CopyPlaced {
+		opts.Sessions = CopyNoSessions
+	}
+
+	var sn Snapshot
+	if src := r.FormValue("source"); src == "current" {
+		mu.RLock()
+		sn = takeSnapshot(opts.Sessions != CopyNoSessions)
+		mu.RUnlock()
+	} else {
+		id, _ := strconv.Atoi(src)
+		t := findTemplateInDB(id)
+		if t == nil {
+			http.NotFound(w, r)
+			return
+		}
+		sn = t.Snapshot
+	}
+
+	mu.Lock()
+	copied, err := startFromSnapshot(r.Context(), e, sn, opts)
+	started := e.ID != 0 && e.ID == currentEventID
+	mu.Unlock()
+	if started {
+		pickEvent(w, e.ID)
+	}
+	if err != nil && !started {
+		http.Redirect(w, r, "/templates?saved="+url.QueryEscape(saveFailed(err)), http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		msg := "Started " + e.Name + ", but not everything was copied: " + err.Error()
+		http.Redirect(w, r, "/blocks?saved="+url.QueryEscape(msg), http.StatusSeeOther)
+		return
+	}
+
+	log.Printf("Started event #%d %q: %d sessions copied", e.ID, e.Name, copied)
+	msg := "Started " + e.Name
+	if copied > 0 {
+		msg += " with " + strconv.Itoa(copied) + " session(s)"
+	}
+	http.Redirect(w, r, "/blocks?saved="+url.QueryEscape(msg+" – check the blocks for the new dates"), http.StatusSeeOther)
+}
+
+// takeSnapshot copies the current event – caller holds mu.
+func takeSnapshot(withSessions bool) Snapshot {
+	sn := Snapshot{SessionLength: sessionLengthMinutes, BreakMinutes: breakMinutes, Day: dayTemplate}
+	if e := findEvent(currentEventID); e != nil {
+		sn.Event = Event{Location: e.Location, TimeZone: e.TimeZone, TimeFormat: e.TimeFormat}
+	}
+	dayIndex := make(map[string]int)
+	for i, d := range scheduleDays() {
+		dayIndex[d] = i
+	}
+
+	for _, c := range sortedClassrooms(classroomsCache) {
+		sn.Classrooms = append(sn.Classrooms, *c)
+	}
+	for _, b := range blocksCache {
+		sn.Blocks = append(sn.Blocks, SnapshotBlock{b, dayIndex[b.Day]})
+	}
+	for _, t := range tracksCache {
+		sn.Tracks = append(sn.Tracks, *t)
+	}
+	if !withSessions {
+		return sn
+	}
+	for _, p := range presentersCache {
+		sn.Presenters = append(sn.Presenters, *p)
+	}
+	for _, sr := range seriesCache {
+		sn.Series = append(sn.Series, *sr)
+	}
+	for _, list := range sessionsCache {
+		for _, s := range list {
+			if !s.isEmpty() {
+				sn.Sessions = append(sn.Sessions, SnapshotSession{s, dayIndex[s.Day]})
+			}
+		}
+	}
+	return sn
+}
+
+// startFromSnapshot creates e, switches to it and fills it from sn as far
+// as opts allow. Tracks, presenters, series and sessions get new IDs and
+// every reference between them is carried over. Returns how many sessions
+// were copied. If e cannot be created or switched to nothing is written;
+// after that an error leaves the event only partly filled – caller holds mu.
+func startFromSnapshot(ctx context.Context, e *Event, sn Snapshot, opts cloneOptions) (int, error) {
+	e.Location, e.TimeZone, e.TimeFormat = sn.Event.Location, sn.Event.TimeZone, sn.Event.TimeFormat
+	if !validClockFormat(e.TimeFormat) {
+		e.TimeFormat = Clock12
+	}
+	if err := saveEventToDB(e); err != nil {
+		return 0, err
+	}
+	if loadEventsFromDB() != nil || !switchEvent(e.ID) { // empty rooms, a generated day of blocks
+		return 0, fmt.Errorf("event #%d was created but could not be opened", e.ID)
+	}
+	days := eventDays(e)
+
+	if opts.Blocks {
+		sc := Schedule{Settings: dayTemplateSettings(sn.Day)}
+		sc.Settings["session_length_minutes"] = strconv.Itoa(sn.SessionLength)
+		sc.Settings["break_minutes"] = strconv.Itoa(sn.BreakMinutes)
+		for _, b := range sn.Blocks {
+			if b.DayIndex < len(days) {
+				b.Block.Day = days[b.DayIndex]
+				sc.Blocks = append(sc.Blocks, b.Block)
+			}
+		}
+		sortBlocks(sc.Blocks) // nil when no block fits – the generated day stays
+		if err := saveSchedule(ctx, sc); err != nil {
+			return 0, err
+		}
+		sessionLengthMinutes, breakMinutes = sn.SessionLength, sn.BreakMinutes
+		dayTemplate = sn.Day
+	}
+	if opts.Rooms {
+		rooms := make(map[int]*Classroom)
+		for _, c := range sn.Classrooms {
+			c := c
+			rooms[c.ID] = &c
+		}
+		if err := saveSchedule(ctx, Schedule{Classrooms: rooms}); err != nil {
+			return 0, err
+		}
+	}
+
+	if !opts.Tracks && opts.Sessions == CopyNoSessions {
+		return 0, nil
+	}
+
+	// Tracks, and with the sessions their presenters and series, are saved
+	// together; the copies take stand-in IDs until then
+	type copied struct{ cid, idx, oldID int }
+	var order []copied
+	err := editSessionLists(ctx, func(sc *Schedule) error {
+		trackIDs := make(map[int]int)
+		for _, t := range sn.Tracks {
+			t := t
+			trackIDs[t.ID] = sc.newID()
+			t.ID = trackIDs[t.ID]
+			sc.Tracks = append(sc.Tracks, &t)
+		}
+		if opts.Sessions == CopyNoSessions {
+			return nil
+		}
+
+		presenterIDs := make(map[int]int)
+		for _, p := range sn.Presenters {
+			p := p
+			presenterIDs[p.ID] = sc.newID()
+			p.ID = presenterIDs[p.ID]
+			sc.Presenters = append(sc.Presenters, &p)
+		}
+		seriesIDs := make(map[int]int)
+		for _, sr := range sn.Series {
+			sr := sr
+			seriesIDs[sr.ID] = sc.newID()
+			sr.ID = seriesIDs[sr.ID]
+			sc.Series = append(sc.Series, &sr)
+		}
+
+		// Sessions whose room or block did not come along wait in the pool
+		sessions := sc.Sessions
+		for _, ss := range sn.Sessions {
+			s := ss.Session
+			old := s.ID
+			s.ID = 0
+			s.TrackID = trackIDs[s.TrackID]
+			s.SeriesID = seriesIDs[s.SeriesID]
+			if s.SeriesID == 0 {
+				s.Part = 0
+			}
+			var ids []int
+			for _, pid := range s.PresenterIDs {
+				if id := presenterIDs[pid]; id != 0 {
+					ids = append(ids, id)
+				}
+			}
+			s.PresenterIDs = ids
+
+			cid := s.ClassroomID
+			placed := opts.Sessions == CopyPlaced && cid != 0 && ss.DayIndex < len(days) &&
+				classroomsCache[cid] != nil && findBlock(s.BlockID) != nil
+			if placed {
+				s.Day = days[ss.DayIndex]
+			} else {
+				cid = 0
+				s.ClassroomID, s.BlockID, s.Span = 0, 0, 1
+				s.Day, s.StartTime, s.EndTime = "", "", ""
+			}
+			sessions[cid] = append(sessions[cid], s)
+			order = append(order, copied{cid, len(sessions[cid]) - 1, old})
+		}
+		return nil
+	})
+	if err != nil || opts.Sessions == CopyNoSessions {
+		return 0, err
+	}
+
+	// Repeat rounds point at their original's new ID, known once saved
+	sessionIDs := make(map[int]int)
+	for _, c := range order {
+		sessionIDs[c.oldID] = sessionsCache[c.cid][c.idx].ID
+	}
+	err = editSessions(ctx, func(sessions map[int][]Session) error {
+		for _, c := range order {
+			s := &sessions[c.cid][c.idx]
+			s.RepeatOf = sessionIDs[s.RepeatOf]
+		}
+		syncRepeats(sessions)
+		return nil
+	})
+	return len(order), err
+}
+
+// Summary counts what a template holds for its card.
+func (t *ScheduleTemplate) Summary() string {
+	sn := t.Snapshot
+	days := 0
+	for _, b := range sn.Blocks {
+		days = max(days, b.DayIndex+1)
+	}
+	out := strconv.Itoa(len(sn.Classrooms)) + " rooms, " + strconv.Itoa(len(sn.Blocks)) + " blocks over " + strconv.Itoa(days) + " day(s)"
+	if len(sn.Tracks) > 0 {
+		out += ", " + strconv.Itoa(len(sn.Tracks)) + " tracks"
+	}
+	if t.WithSessions {
+		out += ", " + strconv.Itoa(len(sn.Sessions)) + " sessions"
+	}
+	return out
+}
+
+func encodeSnapshot(sn Snapshot) string {
+	data, err := json.Marshal(sn)
+	if err != nil {
+		log.Println("Failed to encode template:", err)
+	}
+	return string(data)
+}
+
+func decodeSnapshot(data string) Snapshot {
+	var sn Snapshot
+	if err := json.Unmarshal([]byte(data), &sn); err != nil {
+		log.Println("Failed to read template:", err)
+	}
+	return sn
+}
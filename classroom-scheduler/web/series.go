@@ -0,0 +1,322 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// seriesNewParts is how many blank part pickers each series card offers.
+const seriesNewParts = 2
+
+// SeriesLink points from one part of a series to the one before or after it.
+type SeriesLink struct {
+	Part  int
+	Title string
+	Where string // "Room 101, 09:40–11:10" or "not scheduled yet"
+}
+
+// SeriesCard is one series on /series with its parts in order.
+type SeriesCard struct {
+	*Series
+	Parts    []Session
+	NewParts []int
+	Problems []string
+}
+
+// SessionChoice is a session offered in a part picker.
+type SessionChoice struct {
+	ID    int
+	Label string
+}
+
+// Series page – link sessions that build on each other
+func SeriesHandler(w http.ResponseWriter, r *http.Request) {
+	mu.RLock()
+	problems := seriesProblems(sessionsCache, classroomsCache)
+	var cards []SeriesCard
+	for _, sr := range seriesCache {
+		card := SeriesCard{Series: sr, Parts: seriesParts(sr.ID)}
+		for i := 0; i < seriesNewParts; i++ {
+			card.NewParts = append(card.NewParts, len(card.Parts)+i+1)
+		}
+		for _, p := range card.Parts {
+			if msg := problems[p.ID]; msg != "" {
+				card.Problems = append(card.Problems, msg)
+			}
+		}
+		cards = append(cards, card)
+	}
+	choices := sessionChoices()
+	mu.RUnlock()
+
+	data := struct {
+		Series   []SeriesCard
+		Sessions []SessionChoice
+
+		Active    string
+		PageTitle string
+		Year      int
+		ExtraCSS  []string
+		Flash     string
+	}{
+		Series:   cards,
+		Sessions: choices,
+
+		Active:    "series",
+		PageTitle: "Session Series",
+		Year:      eventNow().Year(),
+		ExtraCSS:  []string{"series.css"},
+		Flash:     r.URL.Query().Get("saved"),
+	}
+	RenderTemplate(w, "series.html", data)
+}
+
+// Create (id empty) or update a series. part_1, part_2, ... pick its
+// sessions in order; blank pickers are skipped.
+func SeriesSaveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	r.ParseForm()
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		http.Redirect(w, r, "/series?saved=Series+name+is+required", http.StatusSeeOther)
+		return
+	}
+
+	// Parts in picker order; a session picked twice keeps its first place
+	var picked []int
+	seen := make(map[int]bool)
+	for i := 1; r.Form.Has("part_" + strconv.Itoa(i)); i++ {
+		sid, _ := strconv.Atoi(r.FormValue("part_" + strconv.Itoa(i)))
+		if sid != 0 && !seen[sid] {
+			seen[sid] = true
+			picked = append(picked, sid)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	id, _ := strconv.Atoi(r.FormValue("id"))
+	var sr *Series
+	err := editSessionLists(r.Context(), func(sc *Schedule) error {
+		if sr = sc.findSeries(id); sr == nil {
+			sr = &Series{ID: sc.newID()}
+			sc.Series = append(sc.Series, sr)
+		}
+		sr.Name = name
+		sr.SameRoom = r.FormValue("same_room") != ""
+		for _, list := range sc.Sessions {
+			for i := range list {
+				if list[i].SeriesID == sr.ID {
+					list[i].SeriesID, list[i].Part = 0, 0
+				}
+			}
+		}
+		for part, sid := range picked {
+			if cid, idx := locateSession(sc.Sessions, sid); idx >= 0 {
+				sc.Sessions[cid][idx].SeriesID = sr.ID
+				sc.Sessions[cid][idx].Part = part + 1
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		http.Redirect(w, r, "/series?saved="+url.QueryEscape(saveFailed(err)), http.StatusSeeOther)
+		return
+	}
+	log.Printf("Saved series #%d %q with %d parts", sr.ID, sr.Name, len(picked))
+
+	msg := "Series saved"
+	if n := len(seriesProblems(sessionsCache, classroomsCache)); n > 0 {
+		msg += " – " + strconv.Itoa(n) + " part(s) are out of order or in the wrong place"
+	}
+	http.Redirect(w, r, "/series?saved="+url.QueryEscape(msg), http.StatusSeeOther)
+}
+
+func SeriesDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	id, _ := strconv.Atoi(r.FormValue("id"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if findSeries(id) == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// The sessions stay where they are, just unlinked
+	err := editSessionLists(r.Context(), func(sc *Schedule) error {
+		kept := sc.Series[:0]
+		for _, sr := range sc.Series {
+			if sr.ID != id {
+				kept = append(kept, sr)
+			}
+		}
+		sc.Series = kept
+		for _, list := range sc.Sessions {
+			for i := range list {
+				if list[i].SeriesID == id {
+					list[i].SeriesID, list[i].Part = 0, 0
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		http.Redirect(w, r, "/series?saved="+url.QueryEscape(saveFailed(err)), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/series?saved=Series+deleted", http.StatusSeeOther)
+}
+
+// findSeries – caller holds mu.
+func findSeries(id int) *Series {
+	return draftSchedule().findSeries(id)
+}
+
+func (sc Schedule) findSeries(id int) *Series {
+	for _, sr := range sc.Series {
+		if sr.ID == id {
+			return sr
+		}
+	}
+	return nil
+}
+
+// seriesParts lists a series' sessions, scheduled or not, in part order –
+// caller holds mu.
+func seriesParts(id int) []Session {
+	return draftSchedule().seriesParts(id)
+}
+
+func (sc Schedule) seriesParts(id int) []Session {
+	var parts []Session
+	for _, list := range sc.Sessions {
+		for _, s := range list {
+			if s.SeriesID == id {
+				parts = append(parts, s)
+			}
+		}
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Part < parts[j].Part })
+	return parts
+}
+
+// sessionChoices is every session with a title, labeled with where it is
+// – caller holds mu.
+func sessionChoices() []SessionChoice {
+	var all []Session
+	for _, list := range sessionsCache {
+		for _, s := range list {
+			if s.Title != "" {
+				all = append(all, s)
+			}
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Title != all[j].Title {
+			return all[i].Title < all[j].Title
+		}
+		return all[i].ID < all[j].ID
+	})
+	choices := make([]SessionChoice, len(all))
+	for i, s := range all {
+		choices[i] = SessionChoice{s.ID, s.Title + " – " + seriesWhere(s)}
+	}
+	return choices
+}
+
+// seriesProblems checks that each series' scheduled parts run in order and
+// stay in one building, or one room when the series asks for it. Problems
+// are keyed by the session ID of the later part – caller holds mu.
+func seriesProblems(sessions map[int][]Session, rooms map[int]*Classroom) map[int]string {
+	bySeries := make(map[int][]Session)
+	for cid, list := range sessions {
+		if cid == 0 {
+			continue
+		}
+		for _, s := range list {
+			if s.SeriesID != 0 && findSeries(s.SeriesID) != nil {
+				bySeries[s.SeriesID] = append(bySeries[s.SeriesID], s)
+			}
+		}
+	}
+
+	problems := make(map[int]string)
+	for id, parts := range bySeries {
+		sr := findSeries(id)
+		sort.Slice(parts, func(i, j int) bool { return parts[i].Part < parts[j].Part })
+		for i := 1; i < len(parts); i++ {
+			prev, cur := parts[i-1], parts[i]
+			what := sr.Name + " Part " + strconv.Itoa(cur.Part)
+			before := "Part " + strconv.Itoa(prev.Part)
+			here, there := buildingOf(rooms, cur.ClassroomID), buildingOf(rooms, prev.ClassroomID)
+			switch {
+			case cur.Day < prev.Day || (cur.Day == prev.Day && cur.StartTime < prev.EndTime):
+				problems[cur.ID] = what + " starts before " + before + " ends"
+			case sr.SameRoom && cur.ClassroomID != prev.ClassroomID:
+				problems[cur.ID] = what + " must be in " + roomName(prev.ClassroomID) + " like " + before
+			case here != "" && there != "" && here != there:
+				problems[cur.ID] = what + " is in " + here + ", " + before + " in " + there
+			}
+		}
+	}
+	return problems
+}
+
+func buildingOf(rooms map[int]*Classroom, cid int) string {
+	if c, ok := rooms[cid]; ok {
+		return c.Building
+	}
+	return ""
+}
+
+// linkSeries fills Continued and Continues on copies of sessions for
+// display – caller holds mu.
+func (sc Schedule) linkSeries(sessions []Session) {
+	for i := range sessions {
+		s := &sessions[i]
+		if s.SeriesID == 0 || s.Spanning != nil {
+			continue
+		}
+		parts := sc.seriesParts(s.SeriesID)
+		for j, p := range parts {
+			if p.ID != s.ID {
+				continue
+			}
+			if j > 0 {
+				s.Continued = sc.seriesLink(parts[j-1])
+			}
+			if j < len(parts)-1 {
+				s.Continues = sc.seriesLink(parts[j+1])
+			}
+		}
+	}
+}
+
+func (sc Schedule) seriesLink(s Session) *SeriesLink {
+	return &SeriesLink{Part: s.Part, Title: sessionLabel(s), Where: sc.seriesWhere(s)}
+}
+
+// seriesWhere – caller holds mu.
+func seriesWhere(s Session) string {
+	return draftSchedule().seriesWhere(s)
+}
+
+func (sc Schedule) seriesWhere(s Session) string {
+	if s.ClassroomID == 0 {
+		return "not scheduled yet"
+	}
+	return sc.roomName(s.ClassroomID) + ", " + timeRange(s.Day, s.StartTime, s.EndTime)
+}
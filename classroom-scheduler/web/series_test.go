@@ -0,0 +1,122 @@
+package web
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSeriesProblems(t *testing.T) {
+	rooms := map[int]*Classroom{
+		1: {ID: 1, Name: "Cascade", Building: "Main"},
+		2: {ID: 2, Name: "Summit", Building: "Main"},
+		3: {ID: 3, Name: "Gym", Building: "Annex"},
+		4: {ID: 4, Name: "Tent"},
+	}
+	oldRooms, oldSeries := classroomsCache, seriesCache
+	t.Cleanup(func() { classroomsCache, seriesCache = oldRooms, oldSeries })
+	classroomsCache = rooms
+	seriesCache = []*Series{{ID: 1, Name: "CAD"}, {ID: 2, Name: "Drives", SameRoom: true}}
+
+	part := func(id, series, n, cid int, day, start, end string) Session {
+		return Session{ID: id, SeriesID: series, Part: n, ClassroomID: cid, Day: day, StartTime: start, EndTime: end}
+	}
+	for _, tc := range []struct {
+		name     string
+		sessions []Session
+		want     map[int]string
+	}{
+		{
+			name: "parts in order in one building",
+			sessions: []Session{
+				part(10, 1, 1, 1, "2026-03-07", "09:00", "09:45"),
+				part(11, 1, 2, 2, "2026-03-07", "10:00", "10:45"),
+				part(12, 1, 3, 1, "2026-03-08", "09:00", "09:45"),
+			},
+			want: map[int]string{},
+		},
+		{
+			name: "part starting before the previous one ends",
+			sessions: []Session{
+				part(10, 1, 1, 1, "2026-03-07", "09:00", "09:45"),
+				part(11, 1, 2, 2, "2026-03-07", "09:30", "10:15"),
+			},
+			want: map[int]string{11: "CAD Part 2 starts before Part 1 ends"},
+		},
+		{
+			name: "part on an earlier day",
+			sessions: []Session{
+				part(10, 1, 1, 1, "2026-03-08", "09:00", "09:45"),
+				part(11, 1, 2, 1, "2026-03-07", "13:00", "13:45"),
+			},
+			want: map[int]string{11: "CAD Part 2 starts before Part 1 ends"},
+		},
+		{
+			name: "part right after the previous one",
+			sessions: []Session{
+				part(10, 1, 1, 1, "2026-03-07", "09:00", "09:45"),
+				part(11, 1, 2, 1, "2026-03-07", "09:45", "10:30"),
+			},
+			want: map[int]string{},
+		},
+		{
+			name: "part in another building",
+			sessions: []Session{
+				part(10, 1, 1, 1, "2026-03-07", "09:00", "09:45"),
+				part(11, 1, 2, 3, "2026-03-07", "10:00", "10:45"),
+			},
+			want: map[int]string{11: "CAD Part 2 is in Annex, Part 1 in Main"},
+		},
+		{
+			name: "room without a building",
+			sessions: []Session{
+				part(10, 1, 1, 1, "2026-03-07", "09:00", "09:45"),
+				part(11, 1, 2, 4, "2026-03-07", "10:00", "10:45"),
+			},
+			want: map[int]string{},
+		},
+		{
+			name: "same-room series in another room",
+			sessions: []Session{
+				part(20, 2, 1, 1, "2026-03-07", "09:00", "09:45"),
+				part(21, 2, 2, 1, "2026-03-07", "10:00", "10:45"),
+				part(22, 2, 3, 2, "2026-03-07", "11:00", "11:45"),
+			},
+			want: map[int]string{22: "Drives Part 3 must be in Cascade like Part 2"},
+		},
+		{
+			name: "order is checked before the room",
+			sessions: []Session{
+				part(20, 2, 1, 1, "2026-03-07", "10:00", "10:45"),
+				part(21, 2, 2, 3, "2026-03-07", "09:00", "09:45"),
+			},
+			want: map[int]string{21: "Drives Part 2 starts before Part 1 ends"},
+		},
+		{
+			name: "parts compared with the scheduled part before them",
+			sessions: []Session{
+				part(10, 1, 1, 1, "2026-03-07", "09:00", "09:45"),
+				part(12, 1, 3, 3, "2026-03-07", "11:00", "11:45"),
+				{ID: 11, SeriesID: 1, Part: 2},
+			},
+			want: map[int]string{12: "CAD Part 3 is in Annex, Part 1 in Main"},
+		},
+		{
+			name: "series that no longer exists",
+			sessions: []Session{
+				part(30, 9, 1, 1, "2026-03-07", "10:00", "10:45"),
+				part(31, 9, 2, 3, "2026-03-07", "09:00", "09:45"),
+			},
+			want: map[int]string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sessions := make(map[int][]Session)
+			for _, s := range tc.sessions {
+				sessions[s.ClassroomID] = append(sessions[s.ClassroomID], s)
+			}
+			if got := seriesProblems(sessions, rooms); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package web
+
+import "strconv"
+
+// maxSpan is the most consecutive blocks one session may take.
+const maxSpan = 4
+
+// blockRun is the block with the given ID followed by the session blocks
+// after it on the same day, n in all. It stops early at an all-rooms block
+// or the end of the day, so a shorter run means the session doesn't fit.
+// blocks must be sorted with sortBlocks.
+func blockRun(blocks []Block, id, n int) []Block {
+	if n < 1 {
+		n = 1
+	}
+	var run []Block
+	for _, b := range blocks {
+		if len(run) == 0 {
+			if b.ID == id && !b.Spanning() {
+				run = append(run, b)
+			}
+			continue
+		}
+		if len(run) == n || b.Day != run[0].Day || b.Spanning() {
+			break
+		}
+		run = append(run, b)
+	}
+	return run
+}
+
+// sessionBlocks is every block a session takes – caller holds mu.
+func sessionBlocks(s Session) []Block {
+	return draftSchedule().sessionBlocks(s)
+}
+
+func (sc Schedule) sessionBlocks(s Session) []Block {
+	return blockRun(sc.Blocks, s.BlockID, s.Span)
+}
+
+// spanEnd is when a session starting in block id and taking n blocks ends
+// on the blocks' own times, or "" when the run is too short – caller holds mu.
+func spanEnd(id, n int) string {
+	run := blockRun(blocksCache, id, n)
+	if len(run) == 0 || (n > 1 && len(run) < n) {
+		return ""
+	}
+	return run[len(run)-1].EndTime
+}
+
+// runFree reports whether a session taking span blocks from blockID fits in
+// a room's row without running into another session – caller holds mu.
+func runFree(row []Session, blockID, span int) bool {
+	run := blockRun(blocksCache, blockID, span)
+	if len(run) < max(span, 1) {
+		return false
+	}
+	for _, b := range run {
+		if cellTaken(row, b.ID) {
+			return false
+		}
+	}
+	return true
+}
+
+// sessionCovering returns the session in a room that takes the block,
+// whether it starts there or runs on from an earlier block – caller holds mu.
+func sessionCovering(sessions map[int][]Session, cid, blockID int) *Session {
+	list := sessions[cid]
+	for i := range list {
+		for _, b := range sessionBlocks(list[i]) {
+			if b.ID == blockID {
+				return &list[i]
+			}
+		}
+	}
+	return nil
+}
+
+// coveredCells maps every "{cid}_{blockID}" cell a multi-block session runs
+// on into (not the one it starts in) to that session – caller holds mu.
+func coveredCells(sessions map[int][]Session) map[string]Session {
+	covered := make(map[string]Session)
+	for cid, list := range sessions {
+		if cid == 0 {
+			continue
+		}
+		for _, s := range list {
+			run := sessionBlocks(s)
+			for _, b := range run[min(1, len(run)):] {
+				covered[cellKey(cid, b.ID)] = s
+			}
+		}
+	}
+	return covered
+}
+
+func cellKey(cid, blockID int) string {
+	return strconv.Itoa(cid) + "_" + strconv.Itoa(blockID)
+}
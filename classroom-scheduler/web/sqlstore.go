@@ -0,0 +1,767 @@
+package web
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// sqlStore is a Store on database/sql. The SQL is written for SQLite and is
+// plain enough for PostgreSQL too; what does differ is in bind and syncIDs.
+type sqlStore struct {
+	db      *sql.DB
+	bind    func(query string) string // ? placeholders → the driver's
+	syncIDs func(table string) string // moves table's ID counter past rows written with their own ID; nil if it needs no help
+}
+
+// NewSQLiteStore keeps everything in db, the scheduler.db tables created
+// by the migrations.
+func NewSQLiteStore(db *sql.DB) Store {
+	return &sqlStore{db: db, bind: func(q string) string { return q }}
+}
+
+func (st *sqlStore) LoadClassrooms(eventID int) (map[int]*Classroom, error) {
+	rows, err := st.db.Query(st.bind("SELECT id, name, capacity, building FROM classrooms WHERE event_id = ? ORDER BY id"), eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	rooms := make(map[int]*Classroom)
+	for rows.Next() {
+		var c Classroom
+		if err := rows.Scan(&c.ID, &c.Name, &c.Capacity, &c.Building); err != nil {
+			return nil, err
+		}
+		rooms[c.ID] = &c
+	}
+	return rooms, rows.Err()
+}
+
+func (st *sqlStore) LoadBlocks(eventID int) ([]Block, error) {
+	rows, err := st.db.Query(st.bind("SELECT id, day, start_time, end_time, kind, title, location FROM blocks WHERE event_id = ? ORDER BY day, start_time, id"), eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var blocks []Block
+	for rows.Next() {
+		var b Block
+		if err := rows.Scan(&b.ID, &b.Day, &b.StartTime, &b.EndTime, &b.Kind, &b.Title, &b.Location); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, rows.Err()
+}
+
+// LoadSessions returns the event's sessions by room, presenters linked.
+func (st *sqlStore) LoadSessions(eventID int) (map[int][]Session, error) {
+	rows, err := st.db.Query(st.bind("SELECT id, COALESCE(classroom_id, 0), block_id, day, start_time, end_time, title, presenter, description, audience, track_id, tags, level, programs, length_minutes, block_span, series_id, series_part, repeat_of FROM sessions WHERE event_id = ? ORDER BY id"), eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	sessions := make(map[int][]Session)
+	for rows.Next() {
+		var s Session
+		var tags, progs string
+		if err := rows.Scan(&s.ID, &s.ClassroomID, &s.BlockID, &s.Day, &s.StartTime, &s.EndTime, &s.Title, &s.Presenter, &s.Description, &s.Audience, &s.TrackID, &tags, &s.Level, &progs, &s.Length, &s.Span, &s.SeriesID, &s.Part, &s.RepeatOf); err != nil {
+			return nil, err
+		}
+		s.Span = max(s.Span, 1)
+		s.Tags = parseTags(tags)
+		s.Programs = parsePrograms(progs)
+		sessions[s.ClassroomID] = append(sessions[s.ClassroomID], s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	links, err := st.loadSessionPresenters(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("presenters: %w", err)
+	}
+	for _, list := range sessions {
+		for i := range list {
+			list[i].PresenterIDs = links[list[i].ID]
+		}
+	}
+	return sessions, nil
+}
+
+// loadSessionPresenters maps session IDs to their presenters, in order.
+func (st *sqlStore) loadSessionPresenters(eventID int) (map[int][]int, error) {
+	rows, err := st.db.Query(st.bind(`SELECT sp.session_id, sp.presenter_id FROM session_presenters sp
+		JOIN sessions s ON s.id = sp.session_id
+		WHERE s.event_id = ? ORDER BY sp.session_id, sp.position`), eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	links := make(map[int][]int)
+	for rows.Next() {
+		var sid, pid int
+		if err := rows.Scan(&sid, &pid); err != nil {
+			return nil, err
+		}
+		links[sid] = append(links[sid], pid)
+	}
+	return links, rows.Err()
+}
+
+func (st *sqlStore) SaveSchedule(eventID, revision int, s Schedule, batch *ChangeBatch, changes func(Schedule) []Change) (int, error) {
+	tx, err := st.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("start saving: %w", err)
+	}
+	defer tx.Rollback() // no-op once committed
+	rev, err := st.bumpRevision(tx, eventID, revision)
+	if err != nil {
+		return 0, err
+	}
+
+	if s.Classrooms != nil {
+		if err := st.writeClassrooms(tx, eventID, s.Classrooms); err != nil {
+			return 0, fmt.Errorf("save rooms: %w", err)
+		}
+	}
+	if s.Blocks != nil {
+		if err := st.writeBlocks(tx, eventID, s.Blocks); err != nil {
+			return 0, fmt.Errorf("save blocks: %w", err)
+		}
+	}
+	if err := st.writeLists(tx, eventID, s); err != nil {
+		return 0, err
+	}
+	if s.Sessions != nil {
+		if err := st.writeSessions(tx, eventID, s.Sessions); err != nil {
+			return 0, fmt.Errorf("save sessions: %w", err)
+		}
+	}
+	if err := st.writeEventSettings(tx, eventID, s.Settings); err != nil {
+		return 0, fmt.Errorf("save settings: %w", err)
+	}
+	batchID := 0
+	if batch != nil {
+		if batchID, err = st.writeChanges(tx, eventID, batch, changes(s)); err != nil {
+			return 0, fmt.Errorf("log changes: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("save: %w", err)
+	}
+	if batchID != 0 {
+		batch.ID = batchID
+	}
+	return rev, nil
+}
+
+// bumpRevision counts a save of the event and returns the new revision, or
+// ErrStale if the event is no longer at revision. It runs first in a save so
+// the row it locks keeps saves of one event from overlapping.
+func (st *sqlStore) bumpRevision(tx *sql.Tx, eventID, revision int) (int, error) {
+	if _, err := tx.Exec(st.bind("INSERT INTO schedule_revisions (event_id) VALUES (?) ON CONFLICT (event_id) DO NOTHING"), eventID); err != nil {
+		return 0, fmt.Errorf("count the save: %w", err)
+	}
+	var rev int
+	err := tx.QueryRow(st.bind("UPDATE schedule_revisions SET revision = revision + 1 WHERE event_id = ? AND revision = ? RETURNING revision"),
+		eventID, revision).Scan(&rev)
+	if err == sql.ErrNoRows {
+		return 0, ErrStale
+	}
+	if err != nil {
+		return 0, fmt.Errorf("count the save: %w", err)
+	}
+	return rev, nil
+}
+
+func (st *sqlStore) Revision(eventID int) (int, error) {
+	var rev int
+	err := st.db.QueryRow(st.bind("SELECT revision FROM schedule_revisions WHERE event_id = ?"), eventID).Scan(&rev)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return rev, err
+}
+
+func (st *sqlStore) writeClassrooms(tx *sql.Tx, eventID int, rooms map[int]*Classroom) error {
+	if _, err := tx.Exec(st.bind("DELETE FROM classrooms WHERE event_id = ?"), eventID); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(st.bind("INSERT INTO classrooms (event_id, id, name, capacity, building) VALUES (?, ?, ?, ?, ?)"))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, cl := range rooms {
+		if _, err := stmt.Exec(eventID, cl.ID, cl.Name, cl.Capacity, cl.Building); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (st *sqlStore) writeBlocks(tx *sql.Tx, eventID int, blocks []Block) error {
+	if _, err := tx.Exec(st.bind("DELETE FROM blocks WHERE event_id = ?"), eventID); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(st.bind("INSERT INTO blocks (event_id, id, day, start_time, end_time, kind, title, location) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, b := range blocks {
+		if _, err := stmt.Exec(eventID, b.ID, b.Day, b.StartTime, b.EndTime, b.Kind, b.Title, b.Location); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const sessionColumns = "event_id, classroom_id, block_id, day, start_time, end_time, title, presenter, description, audience, track_id, tags, level, programs, length_minutes, block_span, series_id, series_part, repeat_of"
+
+func sessionValues(eventID, classroomID int, s *Session) []any {
+	return []any{eventID, classroomID, s.BlockID, s.Day, s.StartTime, s.EndTime, s.Title, s.Presenter, s.Description, s.Audience, s.TrackID, strings.Join(s.Tags, ","), s.Level, strings.Join(s.Programs, ","), s.Length, max(s.Span, 1), s.SeriesID, s.Part, s.RepeatOf}
+}
+
+// writeSessions writes the sessions that have an ID first and then the new
+// ones, which get theirs from the database.
+func (st *sqlStore) writeSessions(tx *sql.Tx, eventID int, sessions map[int][]Session) error {
+	if _, err := tx.Exec(st.bind("DELETE FROM session_presenters WHERE session_id IN (SELECT id FROM sessions WHERE event_id = ?)"), eventID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(st.bind("DELETE FROM sessions WHERE event_id = ?"), eventID); err != nil {
+		return err
+	}
+	params := strings.TrimSuffix(strings.Repeat("?, ", strings.Count(sessionColumns, ",")+1), ", ")
+	stmt, err := tx.Prepare(st.bind("INSERT INTO sessions (id, " + sessionColumns + ") VALUES (?, " + params + ")"))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	newStmt, err := tx.Prepare(st.bind("INSERT INTO sessions (" + sessionColumns + ") VALUES (" + params + ") RETURNING id"))
+	if err != nil {
+		return err
+	}
+	defer newStmt.Close()
+	linkStmt, err := tx.Prepare(st.bind("INSERT INTO session_presenters (session_id, presenter_id, position) VALUES (?, ?, ?) ON CONFLICT DO NOTHING"))
+	if err != nil {
+		return err
+	}
+	defer linkStmt.Close()
+
+	type added struct {
+		classroomID int
+		s           *Session
+	}
+	var fresh []added
+	for classroomID, list := range sessions {
+		for i := range list {
+			s := &list[i]
+			if s.ID == 0 {
+				fresh = append(fresh, added{classroomID, s})
+				continue
+			}
+			if _, err := stmt.Exec(append([]any{s.ID}, sessionValues(eventID, classroomID, s)...)...); err != nil {
+				return err
+			}
+		}
+	}
+	if st.syncIDs != nil && len(fresh) > 0 {
+		if _, err := tx.Exec(st.syncIDs("sessions")); err != nil {
+			return err
+		}
+	}
+	for _, a := range fresh {
+		if err := newStmt.QueryRow(sessionValues(eventID, a.classroomID, a.s)...).Scan(&a.s.ID); err != nil {
+			return err
+		}
+	}
+
+	for _, list := range sessions {
+		for _, s := range list {
+			for pos, pid := range s.PresenterIDs {
+				if _, err := linkStmt.Exec(s.ID, pid, pos); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// writeChanges adds changes to batch, starting it if it has no ID yet, and
+// returns the batch's ID – 0 when there was nothing to log.
+func (st *sqlStore) writeChanges(tx *sql.Tx, eventID int, batch *ChangeBatch, changes []Change) (int, error) {
+	if len(changes) == 0 {
+		return batch.ID, nil
+	}
+	batchID := batch.ID
+	if batchID == 0 {
+		err := tx.QueryRow(st.bind(`INSERT INTO change_batches (event_id, at, "user", action) VALUES (?, ?, ?, ?) RETURNING id`),
+			eventID, batch.At, batch.User, batch.Action).Scan(&batchID)
+		if err != nil {
+			return 0, err
+		}
+	}
+	stmt, err := tx.Prepare(st.bind("INSERT INTO changes (batch_id, event_id, entity, entity_id, label, field, old_value, new_value) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	for _, c := range changes {
+		if _, err := stmt.Exec(batchID, eventID, c.Entity, c.EntityID, c.Label, c.Field, c.Old, c.New); err != nil {
+			return 0, err
+		}
+	}
+	return batchID, nil
+}
+
+func (st *sqlStore) LoadHistory(eventID, limit int) ([]ChangeBatch, error) {
+	rows, err := st.db.Query(st.bind(`SELECT id, at, "user", action FROM change_batches WHERE event_id = ? ORDER BY id DESC LIMIT ?`), eventID, limit)
+	if err != nil {
+		return nil, err
+	}
+	var batches []ChangeBatch
+	index := make(map[int]int)
+	for rows.Next() {
+		var b ChangeBatch
+		if err := rows.Scan(&b.ID, &b.At, &b.User, &b.Action); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		index[b.ID] = len(batches)
+		batches = append(batches, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(batches) == 0 {
+		return nil, err
+	}
+
+	changes, err := st.queryChanges("SELECT "+changeColumns+" FROM changes WHERE event_id = ? AND batch_id >= ? ORDER BY id",
+		eventID, batches[len(batches)-1].ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range changes {
+		if i, ok := index[c.BatchID]; ok {
+			batches[i].Changes = append(batches[i].Changes, c)
+		}
+	}
+	return batches, nil
+}
+
+func (st *sqlStore) LoadChanges(eventID, changeID, batchID int) ([]Change, error) {
+	return st.queryChanges("SELECT "+changeColumns+" FROM changes WHERE event_id = ? AND reverted = 0 AND (id = ? OR batch_id = ?) ORDER BY id",
+		eventID, changeID, batchID)
+}
+
+const changeColumns = "id, batch_id, event_id, entity, entity_id, label, field, old_value, new_value, reverted"
+
+func (st *sqlStore) queryChanges(query string, args ...any) ([]Change, error) {
+	rows, err := st.db.Query(st.bind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []Change
+	for rows.Next() {
+		var c Change
+		if err := rows.Scan(&c.ID, &c.BatchID, &c.EventID, &c.Entity, &c.EntityID, &c.Label, &c.Field, &c.Old, &c.New, &c.Reverted); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+func (st *sqlStore) MarkReverted(changes []Change) error {
+	tx, err := st.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // no-op once committed
+	for _, c := range changes {
+		if _, err := tx.Exec(st.bind("UPDATE changes SET reverted = 1 WHERE id = ?"), c.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteEvent removes the event with everything kept for it.
+func (st *sqlStore) DeleteEvent(eventID, revision int) (int, error) {
+	tx, err := st.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // no-op once committed
+	rev, err := st.bumpRevision(tx, eventList, revision)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, q := range []string{
+		"DELETE FROM session_presenters WHERE session_id IN (SELECT id FROM sessions WHERE event_id = ?)",
+		"DELETE FROM sessions WHERE event_id = ?",
+		"DELETE FROM blocks WHERE event_id = ?",
+		"DELETE FROM classrooms WHERE event_id = ?",
+		"DELETE FROM changes WHERE event_id = ?",
+		"DELETE FROM change_batches WHERE event_id = ?",
+		"DELETE FROM event_settings WHERE event_id = ?",
+		"DELETE FROM schedule_revisions WHERE event_id = ?",
+		"DELETE FROM presenters WHERE event_id = ?",
+		"DELETE FROM tracks WHERE event_id = ?",
+		"DELETE FROM series WHERE event_id = ?",
+		"DELETE FROM publications WHERE event_id = ?",
+		"DELETE FROM events WHERE id = ?",
+	} {
+		if _, err := tx.Exec(st.bind(q), eventID); err != nil {
+			return 0, err
+		}
+	}
+	return rev, tx.Commit()
+}
+
+func (st *sqlStore) Setting(key string) (string, error) {
+	var val sql.NullString
+	err := st.db.QueryRow(st.bind("SELECT value FROM settings WHERE key = ?"), key).Scan(&val)
+	return val.String, err
+}
+
+func (st *sqlStore) SaveSetting(key, value string) error {
+	_, err := st.db.Exec(st.bind("INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value"), key, value)
+	return err
+}
+
+func (st *sqlStore) EventSetting(eventID int, key string) (string, error) {
+	var val sql.NullString
+	err := st.db.QueryRow(st.bind("SELECT value FROM event_settings WHERE event_id = ? AND key = ?"), eventID, key).Scan(&val)
+	return val.String, err
+}
+
+func (st *sqlStore) writeEventSettings(tx *sql.Tx, eventID int, settings map[string]string) error {
+	for key, value := range settings {
+		if _, err := tx.Exec(st.bind("INSERT INTO event_settings (event_id, key, value) VALUES (?, ?, ?) ON CONFLICT (event_id, key) DO UPDATE SET value = excluded.value"), eventID, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (st *sqlStore) LoadEvents() ([]*Event, error) {
+	rows, err := st.db.Query("SELECT id, name, location, start_date, end_date, time_zone, time_format FROM events ORDER BY start_date DESC, id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []*Event
+	for rows.Next() {
+		var e Event
+		if err := rows.Scan(&e.ID, &e.Name, &e.Location, &e.StartDate, &e.EndDate, &e.TimeZone, &e.TimeFormat); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+func (st *sqlStore) SaveEvent(e *Event, revision int) (int, error) {
+	tx, err := st.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // no-op once committed
+	rev, err := st.bumpRevision(tx, eventList, revision)
+	if err != nil {
+		return 0, err
+	}
+
+	id := e.ID
+	if id != 0 {
+		_, err = tx.Exec(st.bind("UPDATE events SET name = ?, location = ?, start_date = ?, end_date = ?, time_zone = ?, time_format = ? WHERE id = ?"),
+			e.Name, e.Location, e.StartDate, e.EndDate, e.TimeZone, e.TimeFormat, id)
+	} else {
+		err = tx.QueryRow(st.bind("INSERT INTO events (name, location, start_date, end_date, time_zone, time_format) VALUES (?, ?, ?, ?, ?, ?) RETURNING id"),
+			e.Name, e.Location, e.StartDate, e.EndDate, e.TimeZone, e.TimeFormat).Scan(&id)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	e.ID = id
+	return rev, nil
+}
+
+func (st *sqlStore) LoadPresenters(eventID int) ([]*Presenter, error) {
+	rows, err := st.db.Query(st.bind("SELECT id, name, team_number, email, bio FROM presenters WHERE event_id = ? ORDER BY LOWER(name), id"), eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*Presenter
+	for rows.Next() {
+		var p Presenter
+		if err := rows.Scan(&p.ID, &p.Name, &p.TeamNumber, &p.Email, &p.Bio); err != nil {
+			return nil, err
+		}
+		list = append(list, &p)
+	}
+	return list, rows.Err()
+}
+
+func (st *sqlStore) LoadTracks(eventID int) ([]*Track, error) {
+	rows, err := st.db.Query(st.bind("SELECT id, name, color FROM tracks WHERE event_id = ? ORDER BY position, id"), eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*Track
+	for rows.Next() {
+		var t Track
+		if err := rows.Scan(&t.ID, &t.Name, &t.Color); err != nil {
+			return nil, err
+		}
+		list = append(list, &t)
+	}
+	return list, rows.Err()
+}
+
+func (st *sqlStore) LoadSeries(eventID int) ([]*Series, error) {
+	rows, err := st.db.Query(st.bind("SELECT id, name, same_room FROM series WHERE event_id = ? ORDER BY LOWER(name), id"), eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*Series
+	for rows.Next() {
+		var sr Series
+		if err := rows.Scan(&sr.ID, &sr.Name, &sr.SameRoom); err != nil {
+			return nil, err
+		}
+		list = append(list, &sr)
+	}
+	return list, rows.Err()
+}
+
+// writeLists replaces the non-nil presenter, track and series lists of s.
+// New entries – ID 0, or a negative stand-in from Schedule.newID – get their
+// IDs, and sessions pointing at a stand-in are pointed at the real ID.
+func (st *sqlStore) writeLists(tx *sql.Tx, eventID int, s Schedule) error {
+	presenterIDs := make(map[int]int)
+	if s.Presenters != nil {
+		rows := make([]idRow, len(s.Presenters))
+		for i, p := range s.Presenters {
+			rows[i] = idRow{p.ID, []any{eventID, p.Name, p.TeamNumber, p.Email, p.Bio}}
+		}
+		ids, err := st.replaceRows(tx, eventID, "presenters", "event_id, name, team_number, email, bio", rows)
+		if err != nil {
+			return fmt.Errorf("save presenters: %w", err)
+		}
+		for i, p := range s.Presenters {
+			presenterIDs[p.ID], p.ID = ids[i], ids[i]
+		}
+	}
+	trackIDs := make(map[int]int)
+	if s.Tracks != nil {
+		rows := make([]idRow, len(s.Tracks))
+		for i, t := range s.Tracks {
+			rows[i] = idRow{t.ID, []any{eventID, t.Name, t.Color, i}}
+		}
+		ids, err := st.replaceRows(tx, eventID, "tracks", "event_id, name, color, position", rows)
+		if err != nil {
+			return fmt.Errorf("save tracks: %w", err)
+		}
+		for i, t := range s.Tracks {
+			trackIDs[t.ID], t.ID = ids[i], ids[i]
+		}
+	}
+	seriesIDs := make(map[int]int)
+	if s.Series != nil {
+		rows := make([]idRow, len(s.Series))
+		for i, sr := range s.Series {
+			rows[i] = idRow{sr.ID, []any{eventID, sr.Name, flag(sr.SameRoom)}}
+		}
+		ids, err := st.replaceRows(tx, eventID, "series", "event_id, name, same_room", rows)
+		if err != nil {
+			return fmt.Errorf("save series: %w", err)
+		}
+		for i, sr := range s.Series {
+			seriesIDs[sr.ID], sr.ID = ids[i], ids[i]
+		}
+	}
+
+	for _, list := range s.Sessions {
+		for i := range list {
+			x := &list[i]
+			if x.TrackID < 0 {
+				x.TrackID = trackIDs[x.TrackID]
+			}
+			if x.SeriesID < 0 {
+				x.SeriesID = seriesIDs[x.SeriesID]
+			}
+			for j, pid := range x.PresenterIDs {
+				if pid < 0 {
+					x.PresenterIDs[j] = presenterIDs[pid]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// idRow is a row for replaceRows: its ID, 0 or below for a new row, and the
+// values of the other columns.
+type idRow struct {
+	id     int
+	values []any
+}
+
+// replaceRows replaces the event's rows of table and returns every row's
+// ID. Rows with an ID are written first, then the new ones get theirs from
+// the database.
+func (st *sqlStore) replaceRows(tx *sql.Tx, eventID int, table, columns string, rows []idRow) ([]int, error) {
+	if _, err := tx.Exec(st.bind("DELETE FROM "+table+" WHERE event_id = ?"), eventID); err != nil {
+		return nil, err
+	}
+	params := strings.TrimSuffix(strings.Repeat("?, ", strings.Count(columns, ",")+1), ", ")
+	ids := make([]int, len(rows))
+	var fresh []int
+	for i, r := range rows {
+		if r.id <= 0 {
+			fresh = append(fresh, i)
+			continue
+		}
+		if _, err := tx.Exec(st.bind("INSERT INTO "+table+" (id, "+columns+") VALUES (?, "+params+")"), append([]any{r.id}, r.values...)...); err != nil {
+			return nil, err
+		}
+		ids[i] = r.id
+	}
+	if st.syncIDs != nil && len(fresh) > 0 {
+		if _, err := tx.Exec(st.syncIDs(table)); err != nil {
+			return nil, err
+		}
+	}
+	for _, i := range fresh {
+		if err := tx.QueryRow(st.bind("INSERT INTO "+table+" ("+columns+") VALUES ("+params+") RETURNING id"), rows[i].values...).Scan(&ids[i]); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// flag is a bool as the 0 or 1 the INTEGER flag columns hold.
+func flag(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (st *sqlStore) LoadPublication(eventID, before int) (*Publication, error) {
+	query := `SELECT id, at, "user", data FROM publications WHERE event_id = ? AND retired = 0`
+	args := []any{eventID}
+	if before != 0 {
+		query += " AND id < ?"
+		args = append(args, before)
+	}
+	p := Publication{EventID: eventID}
+	var data string
+	if err := st.db.QueryRow(st.bind(query+" ORDER BY id DESC LIMIT 1"), args...).Scan(&p.ID, &p.At, &p.User, &data); err != nil {
+		return nil, err
+	}
+	decodePublication(&p, data)
+	return &p, nil
+}
+
+func (st *sqlStore) LoadPublications(eventID, limit int) ([]Publication, error) {
+	rows, err := st.db.Query(st.bind(`SELECT id, at, "user", retired FROM publications WHERE event_id = ? ORDER BY id DESC LIMIT ?`), eventID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []Publication
+	for rows.Next() {
+		p := Publication{EventID: eventID}
+		if err := rows.Scan(&p.ID, &p.At, &p.User, &p.Retired); err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+func (st *sqlStore) SavePublication(p *Publication, revision int) (int, error) {
+	tx, err := st.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // no-op once committed
+	rev, err := st.bumpRevision(tx, p.EventID, revision)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	if err := tx.QueryRow(st.bind(`INSERT INTO publications (event_id, at, "user", data) VALUES (?, ?, ?, ?) RETURNING id`),
+		p.EventID, p.At, p.User, encodePublication(p)).Scan(&id); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	p.ID = id
+	return rev, nil
+}
+
+func (st *sqlStore) RetirePublication(eventID, id, revision int) (int, error) {
+	tx, err := st.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // no-op once committed
+	rev, err := st.bumpRevision(tx, eventID, revision)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(st.bind("UPDATE publications SET retired = 1 WHERE id = ? AND event_id = ?"), id, eventID); err != nil {
+		return 0, err
+	}
+	return rev, tx.Commit()
+}
+
+func (st *sqlStore) LoadTemplates() ([]*ScheduleTemplate, error) {
+	rows, err := st.db.Query("SELECT id, name, created, with_sessions, data FROM schedule_templates ORDER BY created DESC, id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*ScheduleTemplate
+	for rows.Next() {
+		var t ScheduleTemplate
+		var data string
+		if err := rows.Scan(&t.ID, &t.Name, &t.Created, &t.WithSessions, &data); err != nil {
+			return nil, err
+		}
+		t.Snapshot = decodeSnapshot(data)
+		list = append(list, &t)
+	}
+	return list, rows.Err()
+}
+
+func (st *sqlStore) SaveTemplate(t *ScheduleTemplate, eventID, revision int) error {
+	if rev, err := st.Revision(eventID); err != nil {
+		return err
+	} else if rev != revision {
+		return ErrStale
+	}
+	return st.db.QueryRow(st.bind("INSERT INTO schedule_templates (name, created, with_sessions, data) VALUES (?, ?, ?, ?) RETURNING id"),
+		t.Name, t.Created, flag(t.WithSessions), encodeSnapshot(t.Snapshot)).Scan(&t.ID)
+}
+
+func (st *sqlStore) DeleteTemplate(id int) error {
+	_, err := st.db.Exec(st.bind("DELETE FROM schedule_templates WHERE id = ?"), id)
+	return err
+}
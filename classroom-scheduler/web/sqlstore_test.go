@@ -0,0 +1,292 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testStores opens a fresh SQLite store and, when SCHEDULER_TEST_POSTGRES
+// holds a connection URL, the PostgreSQL one. The PostgreSQL database is
+// written to, so point it at one kept for tests.
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scheduler.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := migrateDB(db, path); err != nil {
+		t.Fatal(err)
+	}
+	stores := map[string]Store{"sqlite": NewSQLiteStore(db)}
+
+	if url := os.Getenv("SCHEDULER_TEST_POSTGRES"); url != "" {
+		pg, err := OpenPostgresStore(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { pg.(*sqlStore).db.Close() })
+		stores["postgres"] = pg
+	} else {
+		t.Log("SCHEDULER_TEST_POSTGRES is not set – PostgreSQL skipped")
+	}
+	return stores
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	for name, st := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			e := &Event{Name: "Store test", StartDate: "2026-03-07", EndDate: "2026-03-08", TimeZone: "America/Chicago", TimeFormat: Clock24}
+			listRev := saveTestEvent(t, st, e)
+			if _, err := st.SaveEvent(e, listRev-1); !errors.Is(err, ErrStale) {
+				t.Errorf("event save from an old revision: got %v, want ErrStale", err)
+			}
+			events, err := st.LoadEvents()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := findIn(events, e.ID); got == nil || *got != *e {
+				t.Errorf("event: got %+v, want %+v", got, e)
+			}
+
+			// New presenters, tracks and series go in with the session that
+			// points at them by stand-in ID
+			presenters := []*Presenter{{ID: -1, Name: "Jane Doe", Email: "jane@example.org"}, {ID: -2, Name: "Team 4607", TeamNumber: "4607"}}
+			tracks := []*Track{{ID: -3, Name: "Programming", Color: "#0066cc"}, {ID: -4, Name: "Mechanical", Color: "#cc6600"}}
+			series := []*Series{{ID: -5, Name: "Java", SameRoom: true}}
+			sc := Schedule{
+				Classrooms: map[int]*Classroom{1: {ID: 1, Name: "Cascade", Capacity: 40, Building: "North"}},
+				Blocks:     []Block{{ID: 1, Day: "2026-03-07", StartTime: "09:00", EndTime: "09:45", Kind: BlockSession}},
+				Sessions: map[int][]Session{1: {{
+					BlockID: 1, Span: 1, Day: "2026-03-07", StartTime: "09:00", EndTime: "09:45",
+					Title: "Intro to Java", Presenter: "Jane Doe, Team 4607", PresenterIDs: []int{-2, -1},
+					TrackID: -3, Tags: []string{"java"}, Programs: []string{"FRC"}, SeriesID: -5, Part: 1,
+				}}},
+				Presenters: presenters,
+				Tracks:     tracks,
+				Series:     series,
+			}
+			batch := &ChangeBatch{At: "2026-03-01T12:00:00Z", User: "tester", Action: "Test save"}
+			logged := func(s Schedule) []Change {
+				id := s.Sessions[1][0].ID
+				return []Change{{Entity: EntitySession, EntityID: id, Label: "Intro to Java"}}
+			}
+			rev, err := st.SaveSchedule(e.ID, 0, sc, batch, logged)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rev != 1 {
+				t.Errorf("revision after the first save: got %d, want 1", rev)
+			}
+			saved := sc.Sessions[1][0]
+			if saved.ID == 0 {
+				t.Error("new session got no ID")
+			}
+			if presenters[0].ID <= 0 || tracks[0].ID <= 0 || series[0].ID <= 0 {
+				t.Errorf("new presenters, tracks and series got no IDs: %+v %+v %+v", presenters, tracks, series)
+			}
+			if want := []int{presenters[1].ID, presenters[0].ID}; !reflect.DeepEqual(saved.PresenterIDs, want) ||
+				saved.TrackID != tracks[0].ID || saved.SeriesID != series[0].ID {
+				t.Errorf("session links: got presenters %v, track %d, series %d, want %v, %d, %d",
+					saved.PresenterIDs, saved.TrackID, saved.SeriesID, want, tracks[0].ID, series[0].ID)
+			}
+			if batch.ID == 0 {
+				t.Error("new change batch got no ID")
+			}
+			if _, err := st.SaveSchedule(e.ID, rev-1, sc, nil, nil); !errors.Is(err, ErrStale) {
+				t.Errorf("save from an old revision: got %v, want ErrStale", err)
+			}
+			if got, err := st.Revision(e.ID); err != nil || got != rev {
+				t.Errorf("revision: got %d, %v, want %d", got, err, rev)
+			}
+
+			gotPresenters, err := st.LoadPresenters(e.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotPresenters, presenters) {
+				t.Errorf("presenters: got %+v, want %+v", gotPresenters, presenters)
+			}
+			gotTracks, err := st.LoadTracks(e.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotTracks, tracks) {
+				t.Errorf("tracks: got %+v, want %+v", gotTracks, tracks)
+			}
+			gotSeries, err := st.LoadSeries(e.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotSeries, series) {
+				t.Errorf("series: got %+v, want %+v", gotSeries, series)
+			}
+
+			rooms, err := st.LoadClassrooms(e.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rooms, sc.Classrooms) {
+				t.Errorf("rooms: got %+v, want %+v", rooms, sc.Classrooms)
+			}
+			blocks, err := st.LoadBlocks(e.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(blocks, sc.Blocks) {
+				t.Errorf("blocks: got %+v, want %+v", blocks, sc.Blocks)
+			}
+			sessions, err := st.LoadSessions(e.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sc.Sessions[1][0].ClassroomID = 1
+			if !reflect.DeepEqual(sessions, sc.Sessions) {
+				t.Errorf("sessions: got %+v, want %+v", sessions, sc.Sessions)
+			}
+
+			history, err := st.LoadHistory(e.ID, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(history) != 1 || history[0].ID != batch.ID || history[0].User != "tester" || len(history[0].Changes) != 1 {
+				t.Fatalf("history: got %+v, want batch #%d with one change", history, batch.ID)
+			}
+			changes, err := st.LoadChanges(e.ID, 0, batch.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := st.MarkReverted(changes); err != nil {
+				t.Fatal(err)
+			}
+			if changes, err = st.LoadChanges(e.ID, 0, batch.ID); err != nil || len(changes) != 0 {
+				t.Errorf("changes left after undoing: got %+v, %v", changes, err)
+			}
+
+			for _, v := range []string{"10", "20"} {
+				if rev, err = st.SaveSchedule(e.ID, rev, Schedule{Settings: map[string]string{"break_minutes": v}}, nil, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got, err := st.EventSetting(e.ID, "break_minutes"); err != nil || got != "20" {
+				t.Errorf("event setting: got %q, %v, want 20", got, err)
+			}
+			if _, err := st.EventSetting(e.ID, "no_such_setting"); err != sql.ErrNoRows {
+				t.Errorf("missing event setting: got %v, want sql.ErrNoRows", err)
+			}
+
+			first := &Publication{EventID: e.ID, At: "2026-03-01T12:00:00Z", User: "tester"}
+			second := &Publication{EventID: e.ID, At: "2026-03-02T12:00:00Z"}
+			for _, p := range []*Publication{first, second} {
+				if rev, err = st.SavePublication(p, rev); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := st.SavePublication(&Publication{EventID: e.ID}, rev-1); !errors.Is(err, ErrStale) {
+				t.Errorf("publish from an old revision: got %v, want ErrStale", err)
+			}
+			if p, err := st.LoadPublication(e.ID, 0); err != nil || p.ID != second.ID {
+				t.Errorf("live version: got %+v, %v, want #%d", p, err, second.ID)
+			}
+			if p, err := st.LoadPublication(e.ID, second.ID); err != nil || p.ID != first.ID || p.User != "tester" {
+				t.Errorf("version before #%d: got %+v, %v, want #%d", second.ID, p, err, first.ID)
+			}
+			if _, err := st.RetirePublication(e.ID, second.ID, rev-1); !errors.Is(err, ErrStale) {
+				t.Errorf("rollback from an old revision: got %v, want ErrStale", err)
+			}
+			if rev, err = st.RetirePublication(e.ID, second.ID, rev); err != nil {
+				t.Fatal(err)
+			}
+			versions, err := st.LoadPublications(e.ID, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(versions) != 2 || !versions[0].Retired || versions[1].Retired {
+				t.Errorf("versions: got %+v, want the newer one retired", versions)
+			}
+
+			if _, err := st.DeleteEvent(e.ID, listRev-1); !errors.Is(err, ErrStale) {
+				t.Errorf("event delete from an old revision: got %v, want ErrStale", err)
+			}
+			if _, err := st.DeleteEvent(e.ID, listRev); err != nil {
+				t.Fatal(err)
+			}
+			if sessions, err := st.LoadSessions(e.ID); err != nil || len(sessions) != 0 {
+				t.Errorf("sessions after deleting the event: got %+v, %v", sessions, err)
+			}
+			if list, err := st.LoadPresenters(e.ID); err != nil || len(list) != 0 {
+				t.Errorf("presenters after deleting the event: got %+v, %v", list, err)
+			}
+			if got, err := st.Revision(e.ID); err != nil || got != 0 {
+				t.Errorf("revision after deleting the event: got %d, %v, want 0", got, err)
+			}
+		})
+	}
+}
+
+func TestStoreTemplates(t *testing.T) {
+	for name, st := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			e := &Event{Name: "Template test"}
+			saveTestEvent(t, st, e)
+			tmpl := &ScheduleTemplate{Name: "Store test", Created: "2026-03-01", WithSessions: true,
+				Snapshot: Snapshot{SessionLength: 45, BreakMinutes: 15, Tracks: []Track{{ID: 1, Name: "Programming", Color: "#0066cc"}}}}
+			if err := st.SaveTemplate(tmpl, e.ID, 1); !errors.Is(err, ErrStale) {
+				t.Errorf("template from an old revision: got %v, want ErrStale", err)
+			}
+			if err := st.SaveTemplate(tmpl, e.ID, 0); err != nil {
+				t.Fatal(err)
+			}
+			defer st.DeleteTemplate(tmpl.ID)
+			list, err := st.LoadTemplates()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got *ScheduleTemplate
+			for _, l := range list {
+				if l.ID == tmpl.ID {
+					got = l
+				}
+			}
+			if got == nil || got.Name != tmpl.Name || !got.WithSessions || !reflect.DeepEqual(got.Snapshot.Tracks, tmpl.Snapshot.Tracks) {
+				t.Errorf("template: got %+v, want %+v", got, tmpl)
+			}
+		})
+	}
+}
+
+// saveTestEvent inserts e, deleted again when the test ends, and returns the
+// event list's revision after that.
+func saveTestEvent(t *testing.T, st Store, e *Event) int {
+	t.Helper()
+	rev, err := st.Revision(eventList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rev, err = st.SaveEvent(e, rev); err != nil {
+		t.Fatal(err)
+	}
+	if e.ID == 0 {
+		t.Fatal("new event got no ID")
+	}
+	t.Cleanup(func() {
+		if rev, err := st.Revision(eventList); err == nil {
+			st.DeleteEvent(e.ID, rev)
+		}
+	})
+	return rev
+}
+
+func findIn(events []*Event, id int) *Event {
+	for _, e := range events {
+		if e.ID == id {
+			return e
+		}
+	}
+	return nil
+}
@@ -19,6 +19,54 @@ var funcMap = template.FuncMap{
 		}
 		return s
 	},
+	"dayLabel": dayLabel,
+	// Times in the current event's format – storage stays "15:04"
+	"clock": func(hhmm string) string {
+		mu.RLock()
+		defer mu.RUnlock()
+		return displayClock(hhmm)
+	},
+	"clockRange": func(start, end string) string {
+		mu.RLock()
+		defer mu.RUnlock()
+		return timeRange("", start, end)
+	},
+	"levelLabel": levelLabel,
+	"hasTag":     hasTag,
+	"hasID": func(ids []int, id int) bool {
+		for _, v := range ids {
+			if v == id {
+				return true
+			}
+		}
+		return false
+	},
+	// Track badge colors
+	"trackOf": func(id int) *Track {
+		mu.RLock()
+		defer mu.RUnlock()
+		return findTrack(id)
+	},
+	// Series notes on /config
+	"seriesOf": func(id int) *Series {
+		mu.RLock()
+		defer mu.RUnlock()
+		return findSeries(id)
+	},
+	// Event switcher in the header
+	"allEvents": func() []*Event {
+		mu.RLock()
+		defer mu.RUnlock()
+		return append([]*Event(nil), eventsCache...)
+	},
+	"currentEvent": func() *Event {
+		mu.RLock()
+		defer mu.RUnlock()
+		if e := findEvent(currentEventID); e != nil {
+			return e
+		}
+		return &Event{}
+	},
 }
 
 // This function parses templates fresh every request → instant live reload!
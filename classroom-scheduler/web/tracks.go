@@ -0,0 +1,325 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// trackPalette colors new tracks in turn until the admin picks their own.
+var trackPalette = []string{"#0066cc", "#e67e22", "#27ae60", "#8e44ad", "#c0392b", "#16a085", "#d4a017", "#7f8c8d"}
+
+// defaultTracks are offered when an event has no tracks yet.
+var defaultTracks = []string{"Programming", "Mechanical", "Electrical", "Business & Outreach", "Strategy"}
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Tracks page – list, create and recolor tracks
+func TracksHandler(w http.ResponseWriter, r *http.Request) {
+	mu.RLock()
+	type row struct {
+		*Track
+		Sessions int
+	}
+	var list []row
+	for _, t := range tracksCache {
+		n := 0
+		for _, sessions := range sessionsCache {
+			for _, s := range sessions {
+				if s.TrackID == t.ID {
+					n++
+				}
+			}
+		}
+		list = append(list, row{t, n})
+	}
+	next := trackPalette[len(tracksCache)%len(trackPalette)]
+	mu.RUnlock()
+
+	data := struct {
+		Tracks    []row
+		NextColor string
+		Defaults  []string
+
+		Active    string
+		PageTitle string
+		Year      int
+		ExtraCSS  []string
+		Flash     string
+	}{
+		Tracks:    list,
+		NextColor: next,
+		Defaults:  defaultTracks,
+
+		Active:    "tracks",
+		PageTitle: "Tracks",
+		Year:      eventNow().Year(),
+		ExtraCSS:  []string{"tracks.css"},
+		Flash:     r.URL.Query().Get("saved"),
+	}
+	RenderTemplate(w, "tracks.html", data)
+}
+
+// Create (id empty) or update a track; defaults=1 adds the standard
+// JUMPSTART tracks that aren't there yet.
+func TracksSaveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	r.ParseForm()
+
+	mu.Lock()
+	defer mu.Unlock()
+	sc := Schedule{Tracks: copyList(tracksCache)}
+
+	if r.FormValue("defaults") != "" {
+		added := 0
+		for _, name := range defaultTracks {
+			if sc.findTrackByName(name) == nil {
+				sc.Tracks = append(sc.Tracks, &Track{ID: sc.newID(), Name: name, Color: trackPalette[len(sc.Tracks)%len(trackPalette)]})
+				added++
+			}
+		}
+		if err := saveSchedule(r.Context(), sc); err != nil {
+			http.Redirect(w, r, "/tracks?saved="+url.QueryEscape(saveFailed(err)), http.StatusSeeOther)
+			return
+		}
+		http.Redirect(w, r, "/tracks?saved="+strconv.Itoa(added)+"+tracks+added", http.StatusSeeOther)
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		http.Redirect(w, r, "/tracks?saved=Track+name+is+required", http.StatusSeeOther)
+		return
+	}
+	color := r.FormValue("color")
+	if !hexColor.MatchString(color) {
+		color = trackPalette[len(sc.Tracks)%len(trackPalette)]
+	}
+
+	id, _ := strconv.Atoi(r.FormValue("id"))
+	t := sc.findTrack(id)
+	if other := sc.findTrackByName(name); other != nil && other != t {
+		http.Redirect(w, r, "/tracks?saved="+url.QueryEscape("There is already a track called "+other.Name), http.StatusSeeOther)
+		return
+	}
+	if t == nil {
+		t = &Track{ID: sc.newID()}
+		sc.Tracks = append(sc.Tracks, t)
+	}
+	t.Name = name
+	t.Color = strings.ToLower(color)
+	if err := saveSchedule(r.Context(), sc); err != nil {
+		http.Redirect(w, r, "/tracks?saved="+url.QueryEscape(saveFailed(err)), http.StatusSeeOther)
+		return
+	}
+	log.Printf("Saved track #%d %q", t.ID, t.Name)
+
+	http.Redirect(w, r, "/tracks?saved=Track+saved", http.StatusSeeOther)
+}
+
+func TrackDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	id, _ := strconv.Atoi(r.FormValue("id"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if findTrack(id) == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Sessions in the track keep everything else
+	err := editSessionLists(r.Context(), func(sc *Schedule) error {
+		kept := sc.Tracks[:0]
+		for _, t := range sc.Tracks {
+			if t.ID != id {
+				kept = append(kept, t)
+			}
+		}
+		sc.Tracks = kept
+		for _, list := range sc.Sessions {
+			for i := range list {
+				if list[i].TrackID == id {
+					list[i].TrackID = 0
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		http.Redirect(w, r, "/tracks?saved="+url.QueryEscape(saveFailed(err)), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/tracks?saved=Track+deleted", http.StatusSeeOther)
+}
+
+// findTrack – caller holds mu.
+func findTrack(id int) *Track {
+	return draftSchedule().findTrack(id)
+}
+
+func (sc Schedule) findTrack(id int) *Track {
+	for _, t := range sc.Tracks {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
+
+// findTrackByName – caller holds mu.
+func findTrackByName(name string) *Track {
+	return draftSchedule().findTrackByName(name)
+}
+
+func (sc Schedule) findTrackByName(name string) *Track {
+	for _, t := range sc.Tracks {
+		if strings.EqualFold(t.Name, name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// formTrack reads a track picker, ignoring unknown IDs – caller holds mu.
+func formTrack(r *http.Request, name string) int {
+	id, _ := strconv.Atoi(r.FormValue(name))
+	if findTrack(id) == nil {
+		return 0
+	}
+	return id
+}
+
+// parseTags splits "vision, beginner,Vision" into ["vision", "beginner"].
+func parseTags(raw string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, t := range strings.Split(raw, ",") {
+		t = strings.TrimSpace(t)
+		if t != "" && !seen[strings.ToLower(t)] {
+			seen[strings.ToLower(t)] = true
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// allTags lists every tag used in the event, sorted – caller holds mu.
+func allTags() []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, list := range sessionsCache {
+		for _, s := range list {
+			for _, t := range s.Tags {
+				if !seen[strings.ToLower(t)] {
+					seen[strings.ToLower(t)] = true
+					tags = append(tags, t)
+				}
+			}
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return strings.ToLower(tags[i]) < strings.ToLower(tags[j]) })
+	return tags
+}
+
+// Filter is the track/tag/level/program filter on the attendee pages, read
+// from ?track=ID&tag=name&level=rookie&program=FTC.
+type Filter struct {
+	Path    string // page the filter links point at
+	TrackID int
+	Tag     string
+	Level   string
+	Program string
+	Tracks  []*Track
+	Tags    []string
+}
+
+// readFilter – caller holds mu.
+func readFilter(r *http.Request) Filter {
+	q := r.URL.Query()
+	f := Filter{Path: r.URL.Path, Tracks: tracksCache, Tags: allTags()}
+	f.TrackID, _ = strconv.Atoi(q.Get("track"))
+	if findTrack(f.TrackID) == nil {
+		f.TrackID = 0
+	}
+	f.Tag = strings.TrimSpace(q.Get("tag"))
+	if validLevel(q.Get("level")) {
+		f.Level = q.Get("level")
+	}
+	if validProgram(q.Get("program")) {
+		f.Program = q.Get("program")
+	}
+	return f
+}
+
+// Active reports whether the filter hides anything.
+func (f Filter) Active() bool {
+	return f.TrackID != 0 || f.Tag != "" || f.Level != "" || f.Program != ""
+}
+
+// Match reports whether a session passes the filter. All-rooms blocks
+// always do, and so do sessions meant for every level or program.
+func (f Filter) Match(s Session) bool {
+	if s.Spanning != nil {
+		return true
+	}
+	if f.TrackID != 0 && s.TrackID != f.TrackID {
+		return false
+	}
+	if f.Tag != "" && !hasTag(s.Tags, f.Tag) {
+		return false
+	}
+	if f.Level != "" && s.Level != "" && s.Level != f.Level {
+		return false
+	}
+	if f.Program != "" && len(s.Programs) > 0 && !hasTag(s.Programs, f.Program) {
+		return false
+	}
+	return true
+}
+
+// With builds a filter URL with one setting changed ("" clears it) and the
+// rest kept.
+func (f Filter) With(key, value string) string {
+	q := url.Values{}
+	if f.TrackID != 0 {
+		q.Set("track", strconv.Itoa(f.TrackID))
+	}
+	for k, v := range map[string]string{"tag": f.Tag, "level": f.Level, "program": f.Program} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	if value == "" || value == "0" {
+		q.Del(key)
+	} else {
+		q.Set(key, value)
+	}
+	if len(q) == 0 {
+		return f.Path
+	}
+	return f.Path + "?" + q.Encode()
+}
+
+// Levels and Programs list the choices for the filter bar.
+func (f Filter) Levels() []string   { return sessionLevels }
+func (f Filter) Programs() []string { return programs }
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -11,6 +11,44 @@ func SetupRoutes() {
 	http.HandleFunc("/classroom/", ClassroomHandler)
 	http.HandleFunc("/config", ConfigHandler)
 	http.HandleFunc("/config/save", ConfigSaveHandler)
+	http.HandleFunc("/config/merge", ConfigMergeHandler)
 	http.HandleFunc("/blocks", BlocksHandler)
 	http.HandleFunc("/blocks/save", BlocksSaveHandler)
+	http.HandleFunc("/blocks/generate", BlocksGenerateHandler)
+	http.HandleFunc("/templates", TemplatesHandler)
+	http.HandleFunc("/templates/save", TemplateSaveHandler)
+	http.HandleFunc("/templates/delete", TemplateDeleteHandler)
+	http.HandleFunc("/templates/start", TemplateStartHandler)
+	http.HandleFunc("/events", EventsHandler)
+	http.HandleFunc("/events/save", EventsSaveHandler)
+	http.HandleFunc("/events/select", EventSelectHandler)
+	http.HandleFunc("/events/live", EventLiveHandler)
+	http.HandleFunc("/events/delete", EventDeleteHandler)
+	http.HandleFunc("/presenters", PresentersHandler)
+	http.HandleFunc("/presenters/save", PresentersSaveHandler)
+	http.HandleFunc("/presenters/delete", PresenterDeleteHandler)
+	http.HandleFunc("/presenters/import", PresentersImportHandler)
+	http.HandleFunc("/presenter/", PresenterHandler)
+	http.HandleFunc("/conflicts", ConflictsHandler)
+	http.HandleFunc("/tracks", TracksHandler)
+	http.HandleFunc("/tracks/save", TracksSaveHandler)
+	http.HandleFunc("/tracks/delete", TrackDeleteHandler)
+	http.HandleFunc("/pool", PoolHandler)
+	http.HandleFunc("/pool/save", PoolSaveHandler)
+	http.HandleFunc("/pool/solve", PoolSolveHandler)
+	http.HandleFunc("/pool/apply", PoolApplyHandler)
+	http.HandleFunc("/grid", GridHandler)
+	http.HandleFunc("/series", SeriesHandler)
+	http.HandleFunc("/series/save", SeriesSaveHandler)
+	http.HandleFunc("/series/delete", SeriesDeleteHandler)
+	http.HandleFunc("/history", HistoryHandler)
+	http.HandleFunc("/history/undo", HistoryUndoHandler)
+	http.HandleFunc("/history/editor", HistoryEditorHandler)
+	http.HandleFunc("/publish", PublishHandler)
+	http.HandleFunc("/publish/save", PublishSaveHandler)
+	http.HandleFunc("/publish/rollback", PublishRollbackHandler)
+	http.HandleFunc("/publish/discard", PublishDiscardHandler)
+
+	// JSON endpoints
+	http.HandleFunc("/api/move", MoveHandler)
 }
\ No newline at end of file